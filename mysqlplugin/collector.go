@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/intelsdi-x/snap-plugin-collector-mysql/stats"
@@ -33,72 +34,270 @@ const (
 	callInnoDB
 	callMaster
 	callSlave
+	callBinlog
+	callHeartbeat
+	callGroup
+	callGalera
+	callApplier
+	callDigest
+	callTableIO
+	callIndexUsage
+	callFileIO
+	callWaits
+	callMemory
+	callAccounts
+	callHistogram
+	callContention
+	callErrors
+	callProgress
+	callTransactions
+
+	// number of defined calls, keep it last
+	callsCount
 )
 
+// callNames maps call ids to names used in configuration and staleness metrics.
+var callNames = map[int]string{
+	callGlobal:       "global",
+	callInnoDB:       "innodb",
+	callMaster:       "master",
+	callSlave:        "slave",
+	callBinlog:       "binlog",
+	callHeartbeat:    "heartbeat",
+	callGroup:        "group",
+	callGalera:       "galera",
+	callApplier:      "applier",
+	callDigest:       "digest",
+	callTableIO:      "table_io",
+	callIndexUsage:   "index_usage",
+	callFileIO:       "file_io",
+	callWaits:        "waits",
+	callMemory:       "memory",
+	callAccounts:     "accounts",
+	callHistogram:    "histogram",
+	callContention:   "contention",
+	callErrors:       "errors",
+	callProgress:     "progress",
+	callTransactions: "transactions",
+}
+
+// optionalCalls lists calls which are discovered only if they succeed.
+var optionalCalls = []int{
+	callMaster, callSlave, callBinlog, callHeartbeat, callGroup, callGalera,
+	callApplier, callDigest, callTableIO, callIndexUsage, callFileIO, callWaits,
+	callMemory, callAccounts, callHistogram, callContention, callErrors, callProgress,
+	callTransactions,
+}
+
+// callTemplates lists names of metrics registered for call regardless of
+// stats returned during discovery, for calls which usually return no stats
+// then.
+var callTemplates = map[int]func() []string{
+	callErrors:   stats.ErrorTemplates,
+	callProgress: stats.ProgressTemplates,
+}
+
 var width32bit = math.Pow(2, 32.0)
 var width64bit = math.Pow(2, 64.0)
 
+// stateExpiry is minimum time after which rate state of requester which
+// stopped collecting is dropped. State of requester collecting less often is
+// kept for stateExpiryIntervals of its collection intervals.
+const (
+	stateExpiry          = 24 * time.Hour
+	stateExpiryIntervals = 3
+)
+
 // NewCollector constructs new metricCollector that will query given statsSource.
 // useInnodb indicates if innodb statistics are gathered (and gathering will
 // fail if they are unavailable).
 func NewCollector(statsSource mysqlSource, useInnodb bool) *metricCollector {
 	self := new(metricCollector)
-	self.counters = map[string]metricValue{}
+	self.states = map[string]*rateState{}
+	self.cache = map[int]cachedResult{}
+	self.Intervals = map[int]time.Duration{}
 	self.UseInnodb = useInnodb
 	self.StatsSource = statsSource
 	return self
 }
 
 // Collect performs given set of calls (indicated by true value in metrics map).
+// Calls are performed concurrently, their results are merged in order of call
+// ids so returned map does not depend on completion order. Call which was
+// performed more recently than its interval (see Intervals) is not repeated,
+// its last result is returned instead.
+// Rates are calculated against previous samples taken for the same rateKey,
+// so requesters collecting at different intervals do not affect each other,
+// also when they share cached results.
+// It's safe to call Collect concurrently.
 // returns map of metric values (accessible by metric name). If any of requested
-// calls fail error is returned.
-func (mc *metricCollector) Collect(metrics map[int]bool) (map[string]interface{}, error) {
+// calls fail error is returned and results of other calls are not cached.
+func (mc *metricCollector) Collect(metrics map[int]bool, rateKey string) (map[string]interface{}, error) {
 
-	res := map[string]interface{}{}
+	now := timeNow()
 
-	if metrics[callGlobal] {
-		st, err := mc.StatsSource.GetStatus(mc.UseInnodb)
-		if err != nil {
-			return nil, err
+	mc.mutex.Lock()
+	requested := []int{}
+	for call := 0; call < callsCount; call++ {
+		if metrics[call] && !mc.isFresh(call, now) {
+			requested = append(requested, call)
 		}
+	}
+	mc.mutex.Unlock()
+
+	results := make([]callResult, len(requested))
 
-		mc.updateStats(res, st)
+	wg := sync.WaitGroup{}
+	for i, call := range requested {
+		wg.Add(1)
+		go func(i, call int) {
+			defer wg.Done()
+			results[i].stats, results[i].err = mc.request(call)
+		}(i, call)
 	}
+	wg.Wait()
 
-	if metrics[callInnoDB] {
-		st, err := mc.StatsSource.GetInnodb()
-		if err != nil {
-			return nil, err
-		}
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	mc.expireStates(now, rateKey)
 
-		mc.updateStats(res, st)
+	state, ok := mc.states[rateKey]
+	if !ok {
+		state = &rateState{counters: map[string]metricValue{}, histograms: map[string]*stats.Histogram{}, derived: map[int]derivedResult{}}
+		mc.states[rateKey] = state
+	}
+	if now.After(state.lastUsed) {
+		if !state.lastUsed.IsZero() {
+			state.interval = now.Sub(state.lastUsed)
+		}
+		state.lastUsed = now
 	}
 
-	if metrics[callMaster] {
-		st, err := mc.StatsSource.GetMasterStatus()
-		if err != nil {
-			return nil, err
+	// cache is updated only if all calls succeeded
+	for _, r := range results {
+		if r.err != nil {
+			return nil, r.err
 		}
+	}
 
-		mc.updateStats(res, st)
+	for i, r := range results {
+		// concurrent collection may have cached more recent result meanwhile
+		if cached, ok := mc.cache[requested[i]]; ok && cached.CollectionTime.After(now) {
+			continue
+		}
+		mc.results++
+		mc.cache[requested[i]] = cachedResult{Stats: r.stats, Tags: statsTags(r.stats), CollectionTime: now, ID: mc.results}
 	}
 
-	if metrics[callSlave] {
-		st, err := mc.StatsSource.GetSlaveStatus()
-		if err != nil {
-			return nil, err
+	res := map[string]interface{}{}
+
+	for call := 0; call < callsCount; call++ {
+		if !metrics[call] {
+			continue
 		}
 
-		mc.updateStats(res, st)
+		cached, ok := mc.cache[call]
+		if !ok {
+			continue
+		}
+
+		for k, v := range state.derive(call, cached) {
+			res[k] = v
+		}
+		res[stalenessName(call)] = now.Sub(cached.CollectionTime).Seconds()
 	}
 
 	return res, nil
 }
 
+// Tags returns tags of metric from the last performed call which returned it,
+// nil is returned for metrics without tags.
+func (mc *metricCollector) Tags(metric string) map[string]string {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	for _, cached := range mc.cache {
+		if tags, ok := cached.Tags[metric]; ok {
+			return tags
+		}
+	}
+	return nil
+}
+
+// expireStates removes rate states of requesters other than the current one
+// which seem to have stopped collecting, they shouldn't hold memory forever.
+func (mc *metricCollector) expireStates(now time.Time, current string) {
+	for k, state := range mc.states {
+		if k != current && now.Sub(state.lastUsed) > state.expiry() {
+			delete(mc.states, k)
+		}
+	}
+}
+
+// isFresh checks if result of call is cached and younger than interval
+// configured for call.
+func (mc *metricCollector) isFresh(call int, now time.Time) bool {
+	cached, ok := mc.cache[call]
+	if !ok {
+		return false
+	}
+	return now.Sub(cached.CollectionTime) < mc.Intervals[call]
+}
+
+// request performs single call indicated by id.
+func (mc *metricCollector) request(call int) (stats.Stats, error) {
+	switch call {
+	case callGlobal:
+		return mc.StatsSource.GetStatus(mc.UseInnodb)
+	case callInnoDB:
+		return mc.StatsSource.GetInnodb()
+	case callMaster:
+		return mc.StatsSource.GetMasterStatus()
+	case callSlave:
+		return mc.StatsSource.GetSlaveStatus()
+	case callBinlog:
+		return mc.StatsSource.GetBinlogs()
+	case callHeartbeat:
+		return mc.StatsSource.GetHeartbeat()
+	case callGroup:
+		return mc.StatsSource.GetGroupReplication()
+	case callGalera:
+		return mc.StatsSource.GetGalera()
+	case callApplier:
+		return mc.StatsSource.GetApplierWorkers()
+	case callDigest:
+		return mc.StatsSource.GetDigests()
+	case callTableIO:
+		return mc.StatsSource.GetTableIO()
+	case callIndexUsage:
+		return mc.StatsSource.GetIndexUsage()
+	case callFileIO:
+		return mc.StatsSource.GetFileIO()
+	case callWaits:
+		return mc.StatsSource.GetWaits()
+	case callMemory:
+		return mc.StatsSource.GetMemory()
+	case callAccounts:
+		return mc.StatsSource.GetAccounts()
+	case callHistogram:
+		return mc.StatsSource.GetHistograms()
+	case callContention:
+		return mc.StatsSource.GetContention()
+	case callErrors:
+		return mc.StatsSource.GetErrors()
+	case callProgress:
+		return mc.StatsSource.GetProgress()
+	case callTransactions:
+		return mc.StatsSource.GetTransactions()
+	}
+	return nil, fmt.Errorf("unknown call: %d", call)
+}
+
 // Discover performs metric discovery. Returns valid metric names and associated
 // Call id's. If mandatory request fails error is returned. No error is returned
-// when master or slave stats can't be read because server may not be configured
-// to work in master-slave mode.
+// when optional calls fail, ex. master or slave stats can't be read because
+// server may not be configured to work in master-slave mode.
 func (mc *metricCollector) Discover() ([]metric, error) {
 	res := []metric{}
 
@@ -116,16 +315,14 @@ func (mc *metricCollector) Discover() ([]metric, error) {
 		addMetrics(&res, st, callInnoDB)
 	}
 
-	// server may not have master or slave stats
-
-	st, err = mc.StatsSource.GetMasterStatus()
-	if err == nil {
-		addMetrics(&res, st, callMaster)
-	}
+	// server may not have master or slave stats, other calls may not be
+	// supported by server version or configuration
 
-	st, err = mc.StatsSource.GetSlaveStatus()
-	if err == nil {
-		addMetrics(&res, st, callSlave)
+	for _, call := range optionalCalls {
+		st, err = mc.request(call)
+		if err == nil {
+			addMetrics(&res, st, call)
+		}
 	}
 
 	return res, nil
@@ -140,6 +337,23 @@ type mysqlSource interface {
 	GetInnodb() (stats.Stats, error)
 	GetMasterStatus() (stats.Stats, error)
 	GetSlaveStatus() (stats.Stats, error)
+	GetBinlogs() (stats.Stats, error)
+	GetHeartbeat() (stats.Stats, error)
+	GetGroupReplication() (stats.Stats, error)
+	GetGalera() (stats.Stats, error)
+	GetApplierWorkers() (stats.Stats, error)
+	GetDigests() (stats.Stats, error)
+	GetTableIO() (stats.Stats, error)
+	GetIndexUsage() (stats.Stats, error)
+	GetFileIO() (stats.Stats, error)
+	GetWaits() (stats.Stats, error)
+	GetMemory() (stats.Stats, error)
+	GetAccounts() (stats.Stats, error)
+	GetHistograms() (stats.Stats, error)
+	GetContention() (stats.Stats, error)
+	GetErrors() (stats.Stats, error)
+	GetProgress() (stats.Stats, error)
+	GetTransactions() (stats.Stats, error)
 	Close() error
 }
 
@@ -156,22 +370,123 @@ type metricValue struct {
 	CollectionTime time.Time
 }
 
+// cachedResult holds stats returned by the last performed call and tags
+// of metrics which have them.
+type cachedResult struct {
+	Stats          stats.Stats
+	Tags           map[string]map[string]string
+	CollectionTime time.Time
+	// identifies result among all results cached by collector
+	ID uint64
+}
+
+// derivedResult holds values computed by requester from cached result
+// identified by ID.
+type derivedResult struct {
+	Values map[string]interface{}
+	ID     uint64
+}
+
+// callResult holds outcome of single call.
+type callResult struct {
+	stats stats.Stats
+	err   error
+}
+
 // metricCollector implements logic for discovering available metrics
 // and associated queries, performing given set of queries and performing
 // rate calculation.
+//
+// Intervals holds minimum time between two subsequent executions of given call,
+// it allows expensive queries to be performed less often than task requests them.
 type metricCollector struct {
 	StatsSource mysqlSource
 	UseInnodb   bool
+	Intervals   map[int]time.Duration
+
+	states map[string]*rateState
+	cache  map[int]cachedResult
+	// number of results cached so far
+	results uint64
+	// guards states, cache and results
+	mutex sync.Mutex
+}
+
+// rateState holds previous values of counters, derives and histograms
+// collected for a single requester and values it computed from cached results.
+type rateState struct {
+	counters   map[string]metricValue
+	histograms map[string]*stats.Histogram
+	derived    map[int]derivedResult
+	lastUsed   time.Time
+	// gap between the last two collections of requester
+	interval time.Duration
+}
 
-	counters map[string]metricValue
+// expiry returns time after which unused state is dropped.
+func (rs *rateState) expiry() time.Duration {
+	if expiry := stateExpiryIntervals * rs.interval; expiry > stateExpiry {
+		return expiry
+	}
+	return stateExpiry
+}
+
+// derive returns values computed from cached result of call. Values are
+// computed once per result, requester which gets the same cached result
+// again receives the same values.
+func (rs *rateState) derive(call int, cached cachedResult) map[string]interface{} {
+	derived, ok := rs.derived[call]
+	if ok && derived.ID == cached.ID {
+		return derived.Values
+	}
+
+	values := map[string]interface{}{}
+	rs.updateStats(values, cached.Stats, cached.CollectionTime)
+	rs.derived[call] = derivedResult{Values: values, ID: cached.ID}
+	return values
 }
 
 // addMetrics appends metric names from st to dst array setting Call
-// field to given value.
+// field to given value. Values of dynamic elements are replaced with names
+// of elements. Staleness metric of call is appended as well.
 func addMetrics(dst *[]metric, st stats.Stats, call int) {
+	// metrics with dynamic elements share single template name
+	added := map[string]bool{}
+
+	names := []string{}
 	for k := range st {
-		*dst = append(*dst, metric{Name: k, Call: call})
+		names = append(names, k)
+	}
+	if templates, ok := callTemplates[call]; ok {
+		names = append(names, templates()...)
+	}
+
+	for _, k := range names {
+		name := templateName(k)
+		if added[name] {
+			continue
+		}
+		added[name] = true
+		*dst = append(*dst, metric{Name: name, Call: call})
 	}
+	*dst = append(*dst, metric{Name: stalenessName(call), Call: call})
+}
+
+// stalenessName returns name of metric which holds age (in seconds) of values
+// returned for given call.
+func stalenessName(call int) string {
+	return "staleness/" + callNames[call]
+}
+
+// statsTags returns tags of stats accessible by metric name.
+func statsTags(st stats.Stats) map[string]map[string]string {
+	res := map[string]map[string]string{}
+	for k, v := range st {
+		if v.Tags != nil {
+			res[k] = v.Tags
+		}
+	}
+	return res
 }
 
 // helper func that converts Stat to nullable value.
@@ -183,32 +498,40 @@ func val(s stats.Stat) interface{} {
 	return s.Value
 }
 
-// updateStats adds metrics from st to res. While gauges are copied as they are, values for
+// updateStats adds metrics from st (collected at collectionTime) to res. While gauges and texts are copied as they are, values for
 // counters and derives are differentiated and represents rate of change in time.
-// and for them send a null on the first measurement (or if the last time was null too)
-func (mc *metricCollector) updateStats(res map[string]interface{}, st stats.Stats) {
+// and for them send a null on the first measurement (or if the last time was null too).
+// Percentiles are calculated from histograms collected since the previous measurement.
+func (rs *rateState) updateStats(res map[string]interface{}, st stats.Stats, collectionTime time.Time) {
 
 	for k, v := range st {
 		switch v.Type {
 		case stats.Gauge:
 			res[k] = val(v)
 
+		case stats.Text:
+			if v.IsNull {
+				res[k] = nil
+			} else {
+				res[k] = v.Text
+			}
+
 		case stats.Derive, stats.Counter:
 			if v.IsNull {
 				res[k] = nil
-				delete(mc.counters, k)
+				delete(rs.counters, k)
 				continue
 			}
 
-			mv := metricValue{Value: v.Value, CollectionTime: timeNow()}
+			mv := metricValue{Value: v.Value, CollectionTime: collectionTime}
 
-			old, ok := mc.counters[k]
+			old, ok := rs.counters[k]
 
 			if !ok {
 				// for metrics representing a rate of change
 				// send a null on the first measurement
 				res[k] = nil
-				mc.counters[k] = mv
+				rs.counters[k] = mv
 				continue
 			}
 
@@ -232,10 +555,26 @@ func (mc *metricCollector) updateStats(res map[string]interface{}, st stats.Stat
 
 			res[k] = delta / mv.CollectionTime.Sub(old.CollectionTime).Seconds()
 
-			mc.counters[k] = mv
+			rs.counters[k] = mv
+
+		case stats.Percentile:
+			old, ok := rs.histograms[k]
+			rs.histograms[k] = v.Histogram
+
+			if !ok {
+				// percentile over interval is known from the second measurement
+				res[k] = nil
+				continue
+			}
+
+			if value, ok := v.Histogram.PercentileSince(old); ok {
+				res[k] = value
+			} else {
+				res[k] = nil
+			}
 
 		default:
-			fmt.Fprintln(os.Stderr, "Metric `", k, "` cannot be classified as a one of the supported data type (gauge, derive or counter)")
+			fmt.Fprintln(os.Stderr, "Metric `", k, "` cannot be classified as a one of the supported data type (gauge, derive, counter, text or percentile)")
 		}
 	}
 }
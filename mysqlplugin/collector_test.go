@@ -23,6 +23,8 @@ package mysqlplugin
 
 import (
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -37,60 +39,91 @@ type statsMock struct {
 }
 
 func (self *statsMock) GetStatus(parseInnodb bool) (stats.Stats, error) {
-	args := self.Mock.Called(parseInnodb)
+	return self.result(self.Mock.Called(parseInnodb))
+}
 
-	r0 := *args.Get(0).(*interface{})
+func (self *statsMock) GetInnodb() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	if r0 == nil {
-		return nil, errors.New("x")
-	}
+func (self *statsMock) GetMasterStatus() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	err, isErr := (r0).(error)
+func (self *statsMock) GetSlaveStatus() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	if isErr {
-		return nil, err
-	}
+func (self *statsMock) GetBinlogs() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	return r0.(stats.Stats), args.Error(1)
+func (self *statsMock) GetHeartbeat() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
 }
 
-func (self *statsMock) GetInnodb() (stats.Stats, error) {
-	args := self.Mock.Called()
+func (self *statsMock) GetGroupReplication() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	r0 := *args.Get(0).(*interface{})
+func (self *statsMock) GetGalera() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	if r0 == nil {
-		return nil, errors.New("x")
-	}
+func (self *statsMock) GetApplierWorkers() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	err, isErr := (r0).(error)
+func (self *statsMock) GetDigests() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	if isErr {
-		return nil, err
-	}
+func (self *statsMock) GetTableIO() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	return r0.(stats.Stats), args.Error(1)
+func (self *statsMock) GetIndexUsage() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
 }
-func (self *statsMock) GetMasterStatus() (stats.Stats, error) {
-	args := self.Mock.Called()
 
-	r0 := *args.Get(0).(*interface{})
+func (self *statsMock) GetFileIO() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	if r0 == nil {
-		return nil, errors.New("x")
-	}
+func (self *statsMock) GetWaits() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	err, isErr := (r0).(error)
+func (self *statsMock) GetMemory() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	if isErr {
-		return nil, err
-	}
+func (self *statsMock) GetAccounts() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
 
-	return r0.(stats.Stats), args.Error(1)
+func (self *statsMock) GetHistograms() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
+
+func (self *statsMock) GetContention() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
+
+func (self *statsMock) GetErrors() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
+
+func (self *statsMock) GetProgress() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
 }
-func (self *statsMock) GetSlaveStatus() (stats.Stats, error) {
-	args := self.Mock.Called()
 
+func (self *statsMock) GetTransactions() (stats.Stats, error) {
+	return self.result(self.Mock.Called())
+}
+
+// result converts arguments of mocked call to returned values
+func (self *statsMock) result(args mock.Arguments) (stats.Stats, error) {
 	r0 := *args.Get(0).(*interface{})
 
 	if r0 == nil {
@@ -103,8 +136,15 @@ func (self *statsMock) GetSlaveStatus() (stats.Stats, error) {
 		return nil, err
 	}
 
-	return r0.(stats.Stats), args.Error(1)
+	// copy, like real source which returns new stats on every call
+	res := stats.Stats{}
+	for k, v := range r0.(stats.Stats) {
+		res[k] = v
+	}
+
+	return res, args.Error(1)
 }
+
 func (self *statsMock) Close() error {
 	args := self.Mock.Called()
 	return args.Error(0)
@@ -123,6 +163,7 @@ func TestDiscover(t *testing.T) {
 		source.On("GetInnodb").Return(mocked.innodbPtr, nil)
 		source.On("GetMasterStatus").Return(mocked.masterPtr, nil)
 		source.On("GetSlaveStatus").Return(mocked.slavePtr, nil)
+		mockOptional(&source, mocked)
 
 		sut := NewCollector(&source, true)
 
@@ -169,6 +210,44 @@ func TestDiscover(t *testing.T) {
 
 				})
 
+				Convey("exposes staleness of data", func() {
+
+					content := map[metric]bool{}
+
+					for _, v := range dut {
+						content[v] = true
+					}
+
+					So(content[metric{Name: "staleness/global", Call: callGlobal}], ShouldBeTrue)
+
+				})
+
+				Convey("exposes progress metrics when no operation is running", func() {
+
+					content := map[metric]bool{}
+
+					for _, v := range dut {
+						content[v] = true
+					}
+
+					So(content[metric{Name: "progress/[thread]/[schema]/[table]/work_completed", Call: callProgress}], ShouldBeTrue)
+					So(content[metric{Name: "progress/[thread]/[schema]/[table]/progress_ppm", Call: callProgress}], ShouldBeTrue)
+
+				})
+
+				Convey("exposes error metrics when no error was raised", func() {
+
+					content := map[metric]bool{}
+
+					for _, v := range dut {
+						content[v] = true
+					}
+
+					So(content[metric{Name: "errors/[error]/raised", Call: callErrors}], ShouldBeTrue)
+					So(content[metric{Name: "errors/[error]/handled", Call: callErrors}], ShouldBeTrue)
+
+				})
+
 			})
 
 		})
@@ -219,6 +298,7 @@ func TestDiscover(t *testing.T) {
 			source.On("GetInnodb").Return(mocked.innodbPtr, nil)
 			source.On("GetMasterStatus").Return(mocked.masterPtr, nil)
 			source.On("GetSlaveStatus").Return(mocked.slavePtr, nil)
+			mockOptional(&source, mocked)
 
 			sut := NewCollector(&source, false)
 
@@ -298,6 +378,34 @@ func TestDiscover(t *testing.T) {
 
 		})
 
+		Convey("tries to request optional data", func() {
+
+			for method, call := range optionalMethods {
+
+				source.AssertCalled(t, method)
+
+				content := map[metric]bool{}
+
+				for _, v := range dut {
+					content[v] = true
+				}
+
+				So(content[metric{Name: callNames[call] + "/stat1", Call: call}], ShouldBeTrue)
+			}
+
+			Convey("does not fail when optional data is unavailable", func() {
+
+				for method := range optionalMethods {
+					*mocked.optional[method] = nil
+				}
+
+				_, dut_err2 := NewCollector(&source, false).Discover()
+
+				So(dut_err2, ShouldBeNil)
+			})
+
+		})
+
 		Convey("returns no error if all requests succeed", func() {
 
 			So(dut_err, ShouldBeNil)
@@ -320,6 +428,7 @@ func TestCollect(t *testing.T) {
 		source.On("GetInnodb").Return(mocked.innodbPtr, nil)
 		source.On("GetMasterStatus").Return(mocked.masterPtr, nil)
 		source.On("GetSlaveStatus").Return(mocked.slavePtr, nil)
+		mockOptional(&source, mocked)
 
 		sut := NewCollector(&source, true)
 
@@ -327,66 +436,166 @@ func TestCollect(t *testing.T) {
 
 			Convey("Global", func() {
 
-				sut.Collect(map[int]bool{callGlobal: true})
+				sut.Collect(map[int]bool{callGlobal: true}, "")
 				source.AssertCalled(t, "GetStatus", true)
 
 			})
 
 			Convey("InnoDB", func() {
 
-				sut.Collect(map[int]bool{callInnoDB: true})
+				sut.Collect(map[int]bool{callInnoDB: true}, "")
 				source.AssertCalled(t, "GetInnodb")
 
 			})
 
 			Convey("MasterStatus", func() {
 
-				sut.Collect(map[int]bool{callMaster: true})
+				sut.Collect(map[int]bool{callMaster: true}, "")
 				source.AssertCalled(t, "GetMasterStatus")
 
 			})
 
 			Convey("SlaveStatus", func() {
 
-				sut.Collect(map[int]bool{callSlave: true})
+				sut.Collect(map[int]bool{callSlave: true}, "")
 				source.AssertCalled(t, "GetSlaveStatus")
 
 			})
 
 		})
 
+		Convey("Should do each requested optional call", func() {
+
+			for method, call := range optionalMethods {
+				sut.Collect(map[int]bool{call: true}, "")
+				source.AssertCalled(t, method)
+			}
+
+		})
+
 		Convey("Doesn't do unnecessary calls", func() {
 
 			Convey("Global", func() {
 
-				sut.Collect(map[int]bool{callInnoDB: true, callMaster: true, callSlave: true})
+				sut.Collect(map[int]bool{callInnoDB: true, callMaster: true, callSlave: true}, "")
 				source.AssertNotCalled(t, "GetStatus")
 
 			})
 
 			Convey("InnoDB", func() {
 
-				sut.Collect(map[int]bool{callGlobal: true, callMaster: true, callSlave: true})
+				sut.Collect(map[int]bool{callGlobal: true, callMaster: true, callSlave: true}, "")
 				source.AssertNotCalled(t, "GetInnodb")
 
 			})
 
 			Convey("MasterStatus", func() {
 
-				sut.Collect(map[int]bool{callGlobal: true, callInnoDB: true, callSlave: true})
+				sut.Collect(map[int]bool{callGlobal: true, callInnoDB: true, callSlave: true}, "")
 				source.AssertNotCalled(t, "GetMasterStatus")
 
 			})
 
 			Convey("SlaveStatus", func() {
 
-				sut.Collect(map[int]bool{callGlobal: true, callMaster: true, callInnoDB: true})
+				sut.Collect(map[int]bool{callGlobal: true, callMaster: true, callInnoDB: true}, "")
 				source.AssertNotCalled(t, "GetSlaveStatus")
 
 			})
 
 		})
 
+		Convey("Performs requested calls concurrently", func() {
+
+			source := statsMock{}
+
+			// every call blocks until all of them are started
+			started := sync.WaitGroup{}
+			started.Add(4)
+			barrier := func(mock.Arguments) {
+				started.Done()
+				started.Wait()
+			}
+
+			source.On("GetStatus", mock.Anything).Return(mocked.statusPtr, nil).Run(barrier)
+			source.On("GetInnodb").Return(mocked.innodbPtr, nil).Run(barrier)
+			source.On("GetMasterStatus").Return(mocked.masterPtr, nil).Run(barrier)
+			source.On("GetSlaveStatus").Return(mocked.slavePtr, nil).Run(barrier)
+
+			sut := NewCollector(&source, true)
+
+			done := make(chan bool)
+			go func() {
+				sut.Collect(map[int]bool{callGlobal: true, callInnoDB: true, callMaster: true, callSlave: true}, "")
+				close(done)
+			}()
+
+			finished := false
+			select {
+			case <-done:
+				finished = true
+			case <-time.After(5 * time.Second):
+			}
+
+			So(finished, ShouldBeTrue)
+
+		})
+
+		Convey("Is safe for concurrent use", func() {
+
+			// run with -race to detect unsynchronized access
+			errs := make(chan error, 8*20)
+			// rates of derive global/stat2 returned for each rate key
+			rates := [2]chan interface{}{make(chan interface{}, 4*20), make(chan interface{}, 4*20)}
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < 20; j++ {
+						res, err := sut.Collect(map[int]bool{callGlobal: true, callInnoDB: true, callMaster: true, callSlave: true}, fmt.Sprintf("task%d", i%2))
+						errs <- err
+						rates[i%2] <- res["global/stat2"]
+					}
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				So(err, ShouldBeNil)
+			}
+
+			// each rate key gets null for its own first measurement (also
+			// when it's returned to concurrent collections of the same key)
+			// and rates of unchanged derive afterwards
+			for _, keyRates := range rates {
+				close(keyRates)
+				nulls := 0
+				for rate := range keyRates {
+					if rate == nil {
+						nulls++
+					} else {
+						So(rate, ShouldEqual, 0)
+					}
+				}
+				So(nulls, ShouldBeGreaterThanOrEqualTo, 1)
+			}
+
+		})
+
+		Convey("Returns error when any of calls fails", func() {
+
+			*mocked.masterPtr = nil
+
+			dut, dut_err := sut.Collect(map[int]bool{callGlobal: true, callMaster: true}, "")
+
+			So(dut, ShouldBeNil)
+			So(dut_err, ShouldNotBeNil)
+
+		})
+
 		Convey("After subsequent calls", func() {
 
 			orgTimeNow := timeNow
@@ -401,7 +610,7 @@ func TestCollect(t *testing.T) {
 			Convey("Gauges are exposed as raw value", func() {
 
 				(*mocked.statusPtr).(stats.Stats)["global/stat0"] = stats.Stat{Value: 10, Type: stats.Gauge, IsNull: false}
-				dut1, _ := sut.Collect(map[int]bool{callGlobal: true})
+				dut1, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
 
 				So(dut1["global/stat0"], ShouldAlmostEqual, 10, 0.1)
 				_, ok := dut1["global/stat0"].(int64)
@@ -410,17 +619,27 @@ func TestCollect(t *testing.T) {
 				timeNow = func() time.Time { return time.Unix(102, 0) }
 
 				(*mocked.statusPtr).(stats.Stats)["global/stat0"] = stats.Stat{Value: 20, Type: stats.Gauge, IsNull: false}
-				dut2, _ := sut.Collect(map[int]bool{callGlobal: true})
+				dut2, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
 
 				So(dut2["global/stat0"], ShouldAlmostEqual, 20, 0.1)
 				_, ok = dut2["global/stat0"].(int64)
 				So(ok, ShouldBeTrue)
 			})
 
+			Convey("Texts are exposed as raw value", func() {
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat3"] = stats.Stat{Text: "Yes", Type: stats.Text, IsNull: false}
+				(*mocked.statusPtr).(stats.Stats)["global/stat4"] = stats.Stat{Type: stats.Text, IsNull: true}
+				dut, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
+
+				So(dut["global/stat3"], ShouldEqual, "Yes")
+				So(dut["global/stat4"], ShouldBeNil)
+			})
+
 			Convey("Derives are exposed as ratio of change to time", func() {
 
 				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 10, Type: stats.Derive, IsNull: false}
-				dut1, _ := sut.Collect(map[int]bool{callGlobal: true})
+				dut1, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
 
 				// derive's rate should be nil on the first measurement
 				So(dut1["global/stat1"], ShouldBeNil)
@@ -428,41 +647,260 @@ func TestCollect(t *testing.T) {
 				timeNow = func() time.Time { return time.Unix(102, 0) }
 
 				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 20, Type: stats.Derive, IsNull: false}
-				dut2, _ := sut.Collect(map[int]bool{callGlobal: true})
+				dut2, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
 
 				So(dut2["global/stat1"], ShouldAlmostEqual, 5, 0.1)
 				_, ok := dut2["global/stat1"].(float64)
 				So(ok, ShouldBeTrue)
 
 				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 20, Type: stats.Derive, IsNull: true}
-				dut3, _ := sut.Collect(map[int]bool{callGlobal: true})
+				dut3, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
 				// derive's rate should be nil when the current value is also nil
 				So(dut3["global/stat1"], ShouldBeNil)
 			})
 
+			Convey("Calls are not repeated within their interval", func() {
+
+				sut.Intervals[callGlobal] = 10 * time.Second
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat0"] = stats.Stat{Value: 10, Type: stats.Gauge, IsNull: false}
+				dut1, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
+
+				So(dut1["global/stat0"], ShouldAlmostEqual, 10, 0.1)
+				So(dut1["staleness/global"], ShouldAlmostEqual, 0, 0.1)
+
+				timeNow = func() time.Time { return time.Unix(105, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat0"] = stats.Stat{Value: 20, Type: stats.Gauge, IsNull: false}
+				dut2, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
+
+				Convey("cached result is returned", func() {
+					So(dut2["global/stat0"], ShouldAlmostEqual, 10, 0.1)
+					source.AssertNumberOfCalls(t, "GetStatus", 1)
+				})
+
+				Convey("with its age", func() {
+					So(dut2["staleness/global"], ShouldAlmostEqual, 5, 0.1)
+				})
+
+				Convey("and are repeated after interval elapses", func() {
+
+					timeNow = func() time.Time { return time.Unix(111, 0) }
+
+					dut3, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
+
+					So(dut3["global/stat0"], ShouldAlmostEqual, 20, 0.1)
+					So(dut3["staleness/global"], ShouldAlmostEqual, 0, 0.1)
+					source.AssertNumberOfCalls(t, "GetStatus", 2)
+
+				})
+
+			})
+
+			Convey("Staleness of queried call is 0 when clock advances during collection", func() {
+
+				// every reading of the clock advances it by a second
+				clock := int64(100)
+				timeNow = func() time.Time {
+					clock++
+					return time.Unix(clock, 0)
+				}
+
+				dut, _ := sut.Collect(map[int]bool{callGlobal: true, callMaster: true}, "")
+
+				So(dut["staleness/global"], ShouldEqual, 0)
+				So(dut["staleness/master"], ShouldEqual, 0)
+
+			})
+
+			Convey("Results are not cached when any of calls fails", func() {
+
+				sut.Intervals[callGlobal] = 10 * time.Second
+
+				*mocked.masterPtr = nil
+
+				_, err := sut.Collect(map[int]bool{callGlobal: true, callMaster: true}, "")
+				So(err, ShouldNotBeNil)
+
+				dut, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
+
+				So(dut, ShouldContainKey, "global/stat1")
+				source.AssertNumberOfCalls(t, "GetStatus", 2)
+
+			})
+
+			Convey("Rates are calculated separately for each rate key", func() {
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 10, Type: stats.Derive, IsNull: false}
+				dutA1, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskA")
+				So(dutA1["global/stat1"], ShouldBeNil)
+
+				timeNow = func() time.Time { return time.Unix(101, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 12, Type: stats.Derive, IsNull: false}
+				dutB1, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskB")
+
+				// first measurement of taskB, even though taskA collected before
+				So(dutB1["global/stat1"], ShouldBeNil)
+
+				timeNow = func() time.Time { return time.Unix(102, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 20, Type: stats.Derive, IsNull: false}
+				dutA2, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskA")
+
+				// taskA's baseline was not overwritten by taskB
+				So(dutA2["global/stat1"], ShouldAlmostEqual, 5, 0.1)
+
+				timeNow = func() time.Time { return time.Unix(103, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 24, Type: stats.Derive, IsNull: false}
+				dutB2, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskB")
+
+				So(dutB2["global/stat1"], ShouldAlmostEqual, 6, 0.1)
+
+			})
+
+			Convey("Rates are calculated separately for each rate key sharing cached result", func() {
+
+				sut.Intervals[callGlobal] = 10 * time.Second
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 10, Type: stats.Derive, IsNull: false}
+				sut.Collect(map[int]bool{callGlobal: true}, "taskA")
+
+				timeNow = func() time.Time { return time.Unix(110, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 30, Type: stats.Derive, IsNull: false}
+				dutA1, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskA")
+
+				So(dutA1["global/stat1"], ShouldAlmostEqual, 2, 0.1)
+
+				timeNow = func() time.Time { return time.Unix(115, 0) }
+
+				dutB1, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskB")
+
+				// cached result is the first measurement of taskB
+				So(dutB1["global/stat1"], ShouldBeNil)
+				source.AssertNumberOfCalls(t, "GetStatus", 2)
+
+				timeNow = func() time.Time { return time.Unix(116, 0) }
+
+				dutA2, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskA")
+
+				// taskA gets the same rate for the same cached result
+				So(dutA2["global/stat1"], ShouldAlmostEqual, 2, 0.1)
+
+				timeNow = func() time.Time { return time.Unix(120, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat1"] = stats.Stat{Value: 60, Type: stats.Derive, IsNull: false}
+				dutB2, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskB")
+
+				// rate is calculated over time between collections of results
+				So(dutB2["global/stat1"], ShouldAlmostEqual, 3, 0.1)
+
+			})
+
+			Convey("Percentiles are calculated separately for each rate key", func() {
+
+				bounds := []int64{1000, 10000, 100000}
+				percentile := func(counts ...int64) stats.Stat {
+					return stats.Stat{Type: stats.Percentile, Histogram: stats.NewHistogram(50, bounds, counts)}
+				}
+
+				(*mocked.statusPtr).(stats.Stats)["global/p50"] = percentile(10, 0, 0)
+				dutA1, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskA")
+
+				// percentile over interval is null on the first measurement
+				So(dutA1["global/p50"], ShouldBeNil)
+
+				timeNow = func() time.Time { return time.Unix(101, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/p50"] = percentile(20, 0, 0)
+				dutB1, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskB")
+
+				So(dutB1["global/p50"], ShouldBeNil)
+
+				timeNow = func() time.Time { return time.Unix(102, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/p50"] = percentile(20, 10, 20)
+				dutA2, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskA")
+
+				// taskA counts statements since its own previous collection
+				So(dutA2["global/p50"], ShouldEqual, 10)
+
+				timeNow = func() time.Time { return time.Unix(103, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/p50"] = percentile(20, 10, 30)
+				dutB2, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskB")
+
+				So(dutB2["global/p50"], ShouldEqual, 100)
+
+				timeNow = func() time.Time { return time.Unix(104, 0) }
+
+				dutB3, _ := sut.Collect(map[int]bool{callGlobal: true}, "taskB")
+
+				// no statements were executed
+				So(dutB3["global/p50"], ShouldBeNil)
+
+			})
+
+			Convey("Tags of collected metrics are kept", func() {
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat0"] = stats.Stat{Value: 10, Type: stats.Gauge, Tags: map[string]string{"tag": "value"}}
+				sut.Collect(map[int]bool{callGlobal: true}, "")
+
+				So(sut.Tags("global/stat0"), ShouldResemble, map[string]string{"tag": "value"})
+				So(sut.Tags("global/stat1"), ShouldBeNil)
+
+			})
+
 			Convey("Counters are exposed as ratio of change to time", func() {
 
 				(*mocked.statusPtr).(stats.Stats)["global/stat2"] = stats.Stat{Value: 10, Type: stats.Counter, IsNull: false}
-				dut1, _ := sut.Collect(map[int]bool{callGlobal: true})
+				dut1, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
 				// counter's rate should be nil on the first measurement
 				So(dut1["global/stat2"], ShouldBeNil)
 
 				timeNow = func() time.Time { return time.Unix(102, 0) }
 
 				(*mocked.statusPtr).(stats.Stats)["global/stat2"] = stats.Stat{Value: 20, Type: stats.Counter, IsNull: false}
-				dut2, _ := sut.Collect(map[int]bool{callGlobal: true})
+				dut2, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
 
 				So(dut2["global/stat2"], ShouldAlmostEqual, 5, 0.1)
 				_, ok := dut2["global/stat2"].(float64)
 				So(ok, ShouldBeTrue)
 
 				(*mocked.statusPtr).(stats.Stats)["global/stat2"] = stats.Stat{Value: 20, Type: stats.Derive, IsNull: true}
-				dut3, _ := sut.Collect(map[int]bool{callGlobal: true})
+				dut3, _ := sut.Collect(map[int]bool{callGlobal: true}, "")
 				// counter's rate should be nil when the current value is also nil
 				So(dut3["global/stat2"], ShouldBeNil)
 
 			})
 
+			Convey("Rates of tasks collecting less often than hourly are kept", func() {
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat2"] = stats.Stat{Value: 10, Type: stats.Counter}
+				sut.Collect(map[int]bool{callGlobal: true}, "slow")
+
+				timeNow = func() time.Time { return time.Unix(100+30*3600, 0) }
+
+				(*mocked.statusPtr).(stats.Stats)["global/stat2"] = stats.Stat{Value: 118, Type: stats.Counter}
+				dut, _ := sut.Collect(map[int]bool{callGlobal: true}, "slow")
+
+				So(dut["global/stat2"], ShouldAlmostEqual, 0.001, 0.0001)
+
+				timeNow = func() time.Time { return time.Unix(100+80*3600, 0) }
+				sut.Collect(map[int]bool{callGlobal: true}, "other")
+
+				// 50 hours is less than three intervals of task
+				So(sut.states, ShouldContainKey, "slow")
+
+				timeNow = func() time.Time { return time.Unix(100+130*3600, 0) }
+				sut.Collect(map[int]bool{callGlobal: true}, "other")
+
+				So(sut.states, ShouldNotContainKey, "slow")
+
+			})
+
 		})
 
 	})
@@ -482,6 +920,37 @@ func mockStat(ns string) *interface{} {
 
 type statMockData struct {
 	statusPtr, innodbPtr, masterPtr, slavePtr *interface{}
+
+	// results of optional calls accessible by mocked method name
+	optional map[string]*interface{}
+}
+
+// optionalMethods maps mocked methods of optional calls to their call ids
+var optionalMethods = map[string]int{
+	"GetBinlogs":          callBinlog,
+	"GetHeartbeat":        callHeartbeat,
+	"GetGroupReplication": callGroup,
+	"GetGalera":           callGalera,
+	"GetApplierWorkers":   callApplier,
+	"GetDigests":          callDigest,
+	"GetTableIO":          callTableIO,
+	"GetIndexUsage":       callIndexUsage,
+	"GetFileIO":           callFileIO,
+	"GetWaits":            callWaits,
+	"GetMemory":           callMemory,
+	"GetAccounts":         callAccounts,
+	"GetHistograms":       callHistogram,
+	"GetContention":       callContention,
+	"GetErrors":           callErrors,
+	"GetProgress":         callProgress,
+	"GetTransactions":     callTransactions,
+}
+
+// mockOptional sets up all methods of optional calls
+func mockOptional(source *statsMock, mocked statMockData) {
+	for method := range optionalMethods {
+		source.On(method).Return(mocked.optional[method], nil)
+	}
 }
 
 func newMockedStats() statMockData {
@@ -491,5 +960,10 @@ func newMockedStats() statMockData {
 	self.masterPtr = mockStat("master")
 	self.slavePtr = mockStat("slave")
 
+	self.optional = map[string]*interface{}{}
+	for method, call := range optionalMethods {
+		self.optional[method] = mockStat(callNames[call])
+	}
+
 	return self
 }
@@ -21,6 +21,7 @@ package mysqlplugin
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +44,21 @@ const (
 	Type = plugin.CollectorPluginType
 )
 
+// defaults for optional connection pool settings
+const (
+	defaultMaxOpenConns = 4
+	defaultMaxIdleConns = 4
+	// in seconds, 0 means connections are reused forever
+	defaultConnMaxLifetime = 0
+)
+
+// defaultIntervals holds minimum refresh intervals (in seconds) of call groups
+// that are expensive to query. Groups not listed are queried on every collection.
+var defaultIntervals = map[int]int{
+	callDigest:     60,
+	callIndexUsage: 60,
+}
+
 // MySQLPlugin is implementation of plugin.Plugin interface.
 type MySQLPlugin struct {
 	initialized      bool
@@ -78,26 +94,57 @@ func (p *MySQLPlugin) CollectMetrics(mts []plugin.MetricType) ([]plugin.MetricTy
 
 	t := time.Now()
 
-	results := make([]plugin.MetricType, len(mts))
-
 	calls := map[int]bool{}
 
 	for _, mt := range mts {
-		name := parseName(mt.Namespace().Strings())
+		name := parseName(mt.Namespace())
 		calls[p.callDiscovery[name]] = true
 	}
 
-	metrics, err := p.mysql.Collect(calls)
+	metrics, err := p.mysql.Collect(calls, rateKey(mts))
 
 	if err != nil {
 		return nil, err
 	}
 
-	for i, mt := range mts {
-		results[i] = plugin.MetricType{
-			Namespace_: mt.Namespace(),
-			Data_:      metrics[parseName(mt.Namespace().Strings())],
-			Timestamp_: t,
+	// names of collected metrics with dynamic elements grouped by their
+	// templates, sorted to keep order of results stable
+	dynamic := map[string][]string{}
+	for k := range metrics {
+		if tmpl := templateName(k); tmpl != k {
+			dynamic[tmpl] = append(dynamic[tmpl], k)
+		}
+	}
+	for _, names := range dynamic {
+		sort.Strings(names)
+	}
+
+	results := []plugin.MetricType{}
+
+	for _, mt := range mts {
+		name := parseName(mt.Namespace())
+
+		if !isDynamicName(name) {
+			results = append(results, plugin.MetricType{
+				Namespace_: mt.Namespace(),
+				Data_:      metrics[name],
+				Tags_:      p.mysql.Tags(name),
+				Timestamp_: t,
+			})
+			continue
+		}
+
+		for _, k := range dynamic[name] {
+			ns, ok := expandNamespace(mt.Namespace(), k)
+			if !ok {
+				continue
+			}
+			results = append(results, plugin.MetricType{
+				Namespace_: ns,
+				Data_:      metrics[k],
+				Tags_:      p.mysql.Tags(k),
+				Timestamp_: t,
+			})
 		}
 	}
 
@@ -116,7 +163,7 @@ func (p *MySQLPlugin) GetMetricTypes(cfg plugin.ConfigType) ([]plugin.MetricType
 	mts := []plugin.MetricType{}
 
 	for k := range p.callDiscovery {
-		mts = append(mts, plugin.MetricType{Namespace_: core.NewNamespace(makeName(k)...)})
+		mts = append(mts, plugin.MetricType{Namespace_: makeNamespace(k)})
 	}
 
 	return mts, nil
@@ -125,6 +172,87 @@ func (p *MySQLPlugin) GetMetricTypes(cfg plugin.ConfigType) ([]plugin.MetricType
 // GetConfigPolicy returns plugin config policy
 func (p *MySQLPlugin) GetConfigPolicy() (*cpolicy.ConfigPolicy, error) {
 	c := cpolicy.New()
+
+	node := cpolicy.NewPolicyNode()
+
+	maxOpen, err := cpolicy.NewIntegerRule("mysql_max_open_conns", false, defaultMaxOpenConns)
+	if err != nil {
+		return nil, err
+	}
+	maxIdle, err := cpolicy.NewIntegerRule("mysql_max_idle_conns", false, defaultMaxIdleConns)
+	if err != nil {
+		return nil, err
+	}
+	lifetime, err := cpolicy.NewIntegerRule("mysql_conn_max_lifetime", false, defaultConnMaxLifetime)
+	if err != nil {
+		return nil, err
+	}
+	node.Add(maxOpen, maxIdle, lifetime)
+
+	hbTable, err := cpolicy.NewStringRule("mysql_heartbeat_table", false, "")
+	if err != nil {
+		return nil, err
+	}
+	hbUTC, err := cpolicy.NewBoolRule("mysql_heartbeat_utc", false, false)
+	if err != nil {
+		return nil, err
+	}
+	hbServerID, err := cpolicy.NewIntegerRule("mysql_heartbeat_server_id", false, 0)
+	if err != nil {
+		return nil, err
+	}
+	node.Add(hbTable, hbUTC, hbServerID)
+
+	digestLimit, err := cpolicy.NewIntegerRule("mysql_digest_limit", false, stats.DefaultDigestLimit)
+	if err != nil {
+		return nil, err
+	}
+	memoryLimit, err := cpolicy.NewIntegerRule("mysql_memory_limit", false, stats.DefaultMemoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	histogramDigests, err := cpolicy.NewBoolRule("mysql_histogram_digests", false, false)
+	if err != nil {
+		return nil, err
+	}
+	contentionLimit, err := cpolicy.NewIntegerRule("mysql_contention_limit", false, stats.DefaultContentionLimit)
+	if err != nil {
+		return nil, err
+	}
+	errorLimit, err := cpolicy.NewIntegerRule("mysql_error_limit", false, stats.DefaultErrorLimit)
+	if err != nil {
+		return nil, err
+	}
+	transactionAccounts, err := cpolicy.NewBoolRule("mysql_transaction_accounts", false, false)
+	if err != nil {
+		return nil, err
+	}
+	node.Add(digestLimit, memoryLimit, histogramDigests, contentionLimit, errorLimit, transactionAccounts)
+
+	rateKey, err := cpolicy.NewStringRule("mysql_rate_key", false)
+	if err != nil {
+		return nil, err
+	}
+	node.Add(rateKey)
+
+	for _, name := range []string{"mysql_schema_include", "mysql_schema_exclude", "mysql_table_include", "mysql_table_exclude",
+		"mysql_file_include", "mysql_file_exclude"} {
+		filter, err := cpolicy.NewStringRule(name, false, "")
+		if err != nil {
+			return nil, err
+		}
+		node.Add(filter)
+	}
+
+	for call, name := range callNames {
+		interval, err := cpolicy.NewIntegerRule(intervalConfigName(name), false, defaultIntervals[call])
+		if err != nil {
+			return nil, err
+		}
+		node.Add(interval)
+	}
+
+	c.Add(namespacePrefix, node)
 	return c, nil
 }
 
@@ -150,13 +278,49 @@ func (p *MySQLPlugin) init(cfg interface{}) error {
 		return fmt.Errorf("plugin initalization failed : [%v]", err)
 	}
 
-	sqlStats, err := makeStats(cfgItems["mysql_connection_string"].(string))
+	opts := stats.Options{
+		MaxOpenConns:    optionalConfigItem(cfg, "mysql_max_open_conns", defaultMaxOpenConns).(int),
+		MaxIdleConns:    optionalConfigItem(cfg, "mysql_max_idle_conns", defaultMaxIdleConns).(int),
+		ConnMaxLifetime: time.Duration(optionalConfigItem(cfg, "mysql_conn_max_lifetime", defaultConnMaxLifetime).(int)) * time.Second,
+
+		HeartbeatTable:    optionalConfigItem(cfg, "mysql_heartbeat_table", "").(string),
+		HeartbeatUTC:      optionalConfigItem(cfg, "mysql_heartbeat_utc", false).(bool),
+		HeartbeatServerID: int64(optionalConfigItem(cfg, "mysql_heartbeat_server_id", 0).(int)),
+
+		DigestLimit: optionalConfigItem(cfg, "mysql_digest_limit", stats.DefaultDigestLimit).(int),
+
+		SchemaInclude: optionalConfigItem(cfg, "mysql_schema_include", "").(string),
+		SchemaExclude: optionalConfigItem(cfg, "mysql_schema_exclude", "").(string),
+		TableInclude:  optionalConfigItem(cfg, "mysql_table_include", "").(string),
+		TableExclude:  optionalConfigItem(cfg, "mysql_table_exclude", "").(string),
+
+		FileInclude: optionalConfigItem(cfg, "mysql_file_include", "").(string),
+		FileExclude: optionalConfigItem(cfg, "mysql_file_exclude", "").(string),
+
+		MemoryLimit: optionalConfigItem(cfg, "mysql_memory_limit", stats.DefaultMemoryLimit).(int),
+
+		HistogramDigests: optionalConfigItem(cfg, "mysql_histogram_digests", false).(bool),
+
+		ContentionLimit: optionalConfigItem(cfg, "mysql_contention_limit", stats.DefaultContentionLimit).(int),
+
+		ErrorLimit: optionalConfigItem(cfg, "mysql_error_limit", stats.DefaultErrorLimit).(int),
+
+		TransactionAccounts: optionalConfigItem(cfg, "mysql_transaction_accounts", false).(bool),
+	}
+
+	sqlStats, err := makeStats(cfgItems["mysql_connection_string"].(string), opts)
 
 	if err != nil {
 		return err
 	}
 
-	p.mysql = makeCollector(sqlStats, cfgItems["mysql_use_innodb"].(bool))
+	intervals := map[int]time.Duration{}
+	for call, name := range callNames {
+		interval := optionalConfigItem(cfg, intervalConfigName(name), defaultIntervals[call]).(int)
+		intervals[call] = time.Duration(interval) * time.Second
+	}
+
+	p.mysql = makeCollector(sqlStats, cfgItems["mysql_use_innodb"].(bool), intervals)
 
 	metrics, err := p.mysql.Discover()
 	if err != nil {
@@ -177,30 +341,154 @@ func (p *MySQLPlugin) init(cfg interface{}) error {
 	return nil
 }
 
+// rateKey identifies requester of metrics, so collector can calculate rates
+// against previous samples of the same task. It's taken from "mysql_rate_key"
+// config item when task provides it, otherwise it's made from the set of
+// requested metrics.
+func rateKey(mts []plugin.MetricType) string {
+	if item, err := config.GetConfigItem(mts[0], "mysql_rate_key"); err == nil {
+		if key, ok := item.(string); ok && key != "" {
+			return key
+		}
+	}
+
+	names := make([]string, len(mts))
+	for i, mt := range mts {
+		names[i] = mt.Namespace().String()
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ",")
+}
+
+// intervalConfigName returns name of config item holding minimum refresh
+// interval (in seconds) of call group with given name.
+func intervalConfigName(callName string) string {
+	return "mysql_interval_" + callName
+}
+
+// optionalConfigItem returns value of config item called name or dflt if
+// item is not present in cfg.
+func optionalConfigItem(cfg interface{}, name string, dflt interface{}) interface{} {
+	v, err := config.GetConfigItem(cfg, name)
+	if err != nil {
+		return dflt
+	}
+	return v
+}
+
 // for mocking
-var makeStats = func(connectionString string) (mysqlSource, error) { return stats.New(connectionString) }
-var makeCollector = func(statsSource mysqlSource, useInnodb bool) collector { return NewCollector(statsSource, useInnodb) }
+var makeStats = func(connectionString string, opts stats.Options) (mysqlSource, error) {
+	return stats.New(connectionString, opts)
+}
+var makeCollector = func(statsSource mysqlSource, useInnodb bool, intervals map[int]time.Duration) collector {
+	c := NewCollector(statsSource, useInnodb)
+	c.Intervals = intervals
+	return c
+}
 
 // prefix of all namespaces
 var namespacePrefix = []string{"intel", "mysql"}
 
 type collector interface {
 	Discover() ([]metric, error)
-	Collect(metrics map[int]bool) (map[string]interface{}, error)
+	Collect(metrics map[int]bool, rateKey string) (map[string]interface{}, error)
+	Tags(metric string) map[string]string
+}
+
+// dynamicDescriptions holds descriptions of dynamic namespace elements.
+var dynamicDescriptions = map[string]string{
+	"channel":     "Name of replication channel, `default` for unnamed channel",
+	"source_uuid": "UUID of server which originated transactions",
+	"server_id":   "server_id of server which wrote heartbeat",
+	"worker":      "Id of replication applier worker",
+	"schema":      "Name of database schema, `none` when no schema was selected",
+	"digest":      "Digest of normalized statement text",
+	"table":       "Name of table",
+	"index":       "Name of index",
+	"file":        "Path of file, with slashes replaced by underscores",
+	"event":       "Name of instrument without wait/ or memory/ prefix, with slashes replaced by underscores",
+	"user":        "Name of user",
+	"host":        "Name of client host",
+	"bucket":      "Number of histogram bucket",
+	"error":       "Name of error, ex. ER_LOCK_DEADLOCK",
+	"thread":      "Performance schema thread id",
 }
 
-// makeName makes namespace from metric path (with segments separated by '/' ) and
-// namespace prefix
-func makeName(m string) []string {
-	res := []string{}
-	res = append(res, namespacePrefix...)
-	res = append(res, strings.Split(m, "/")...)
+// makeNamespace makes namespace from metric path (with segments separated by '/')
+// and namespace prefix. Segments in form of [name] become dynamic elements.
+func makeNamespace(m string) core.Namespace {
+	ns := core.NewNamespace(namespacePrefix...)
+
+	for _, segment := range strings.Split(m, "/") {
+		if strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]") {
+			name := segment[1 : len(segment)-1]
+			ns = ns.AddDynamicElement(name, dynamicDescriptions[name])
+		} else {
+			ns = ns.AddStaticElement(segment)
+		}
+	}
 
-	return res
+	return ns
 }
 
 // parseName extracts metric path from namespace by trimming prefix and concatenating
-// remaining segments with '/'.
-func parseName(ns []string) string {
-	return strings.Join(ns[len(namespacePrefix):], "/")
+// remaining segments with '/'. Dynamic elements are represented as [name], so
+// result matches names returned by Discover.
+func parseName(ns core.Namespace) string {
+	segments := []string{}
+
+	for _, e := range ns[len(namespacePrefix):] {
+		if e.IsDynamic() {
+			segments = append(segments, "["+e.Name+"]")
+		} else {
+			segments = append(segments, e.Value)
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// templateName replaces values of dynamic elements in metric path with their
+// names, ex. a/[channel=x]/b is converted to a/[channel]/b.
+func templateName(m string) string {
+	segments := strings.Split(m, "/")
+
+	for i, segment := range segments {
+		if name, _, ok := stats.ParseDynamic(segment); ok {
+			segments[i] = "[" + name + "]"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// isDynamicName checks if metric path contains dynamic elements.
+func isDynamicName(m string) bool {
+	return strings.Contains(m, "[")
+}
+
+// expandNamespace fills dynamic elements of requested namespace with values
+// from path of collected metric. ok is false when requested namespace
+// has different value of dynamic element set.
+func expandNamespace(ns core.Namespace, m string) (core.Namespace, bool) {
+	res := make(core.Namespace, len(ns))
+	copy(res, ns)
+
+	segments := strings.Split(m, "/")
+
+	for i, e := range res[len(namespacePrefix):] {
+		if !e.IsDynamic() {
+			continue
+		}
+
+		_, value, _ := stats.ParseDynamic(segments[i])
+		if e.Value != "*" && e.Value != value {
+			return nil, false
+		}
+
+		res[len(namespacePrefix)+i].Value = value
+	}
+
+	return res, true
 }
@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/mock"
@@ -52,12 +53,66 @@ func (self *nullSqlsource) GetMasterStatus() (stats.Stats, error) {
 func (self *nullSqlsource) GetSlaveStatus() (stats.Stats, error) {
 	return nil, nil
 }
+func (self *nullSqlsource) GetBinlogs() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetHeartbeat() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetGroupReplication() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetGalera() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetApplierWorkers() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetDigests() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetTableIO() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetIndexUsage() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetFileIO() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetWaits() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetMemory() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetAccounts() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetHistograms() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetContention() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetErrors() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetProgress() (stats.Stats, error) {
+	return nil, nil
+}
+func (self *nullSqlsource) GetTransactions() (stats.Stats, error) {
+	return nil, nil
+}
 func (self *nullSqlsource) Close() error {
 	return nil
 }
 
 type collectorMock struct {
 	mock.Mock
+
+	// tags returned by Tags accessible by metric name
+	tags map[string]map[string]string
 }
 
 func (self *collectorMock) Discover() ([]metric, error) {
@@ -69,8 +124,8 @@ func (self *collectorMock) Discover() ([]metric, error) {
 	return r0, args.Error(1)
 }
 
-func (self *collectorMock) Collect(metrics map[int]bool) (map[string]interface{}, error) {
-	args := self.Called(metrics)
+func (self *collectorMock) Collect(metrics map[int]bool, rateKey string) (map[string]interface{}, error) {
+	args := self.Called(metrics, rateKey)
 	var r0 map[string]interface{} = nil
 	if args.Get(0) != nil {
 		r0 = args.Get(0).(map[string]interface{})
@@ -78,6 +133,10 @@ func (self *collectorMock) Collect(metrics map[int]bool) (map[string]interface{}
 	return r0, args.Error(1)
 }
 
+func (self *collectorMock) Tags(metric string) map[string]string {
+	return self.tags[metric]
+}
+
 func testingConfig() (cfg1 plugin.ConfigType, cfg2 *cdata.ConfigDataNode) {
 	cfg1 = plugin.NewPluginConfigType()
 	cfg2 = cdata.NewNode()
@@ -105,8 +164,8 @@ func TestGetMetricTypes(t *testing.T) {
 
 		mock := &collectorMock{}
 
-		makeStats = func(connectionString string) (mysqlSource, error) { return new(nullSqlsource), nil }
-		makeCollector = func(statsSource mysqlSource, useInnodb bool) collector { return mock }
+		makeStats = func(connectionString string, opts stats.Options) (mysqlSource, error) { return new(nullSqlsource), nil }
+		makeCollector = func(statsSource mysqlSource, useInnodb bool, intervals map[int]time.Duration) collector { return mock }
 
 		cfg1, _ := testingConfig()
 
@@ -139,11 +198,121 @@ func TestGetMetricTypes(t *testing.T) {
 
 		})
 
+		Convey("exposes dynamic elements", func() {
+
+			mock.On("Discover").Return([]metric{metric{Name: "replication/channel/[channel]/read_position", Call: callSlave}}, nil)
+
+			dut, _ := sut.GetMetricTypes(cfg1)
+
+			So(dut, ShouldHaveLength, 1)
+			So(dut[0].Namespace()[4].IsDynamic(), ShouldBeTrue)
+			So(dut[0].Namespace()[4].Name, ShouldEqual, "channel")
+			So(dut[0].Namespace()[5].IsDynamic(), ShouldBeFalse)
+
+		})
+
+		Convey("configures call intervals", func() {
+
+			var dut map[int]time.Duration
+			makeCollector = func(statsSource mysqlSource, useInnodb bool, intervals map[int]time.Duration) collector {
+				dut = intervals
+				return mock
+			}
+
+			mock.On("Discover").Return([]metric{}, nil)
+
+			cfg1.AddItem("mysql_interval_slave", ctypes.ConfigValueInt{Value: 30})
+
+			sut.GetMetricTypes(cfg1)
+
+			So(dut[callSlave], ShouldEqual, 30*time.Second)
+			So(dut[callGlobal], ShouldEqual, 0)
+			So(dut[callDigest], ShouldEqual, 60*time.Second)
+			So(dut[callIndexUsage], ShouldEqual, 60*time.Second)
+
+		})
+
+		Convey("configures connection pool", func() {
+
+			var opts stats.Options
+			makeStats = func(connectionString string, o stats.Options) (mysqlSource, error) {
+				opts = o
+				return new(nullSqlsource), nil
+			}
+
+			mock.On("Discover").Return([]metric{}, nil)
+
+			Convey("with defaults when pool settings are not given", func() {
+
+				sut.GetMetricTypes(cfg1)
+
+				So(opts.MaxOpenConns, ShouldEqual, defaultMaxOpenConns)
+				So(opts.MaxIdleConns, ShouldEqual, defaultMaxIdleConns)
+				So(opts.ConnMaxLifetime, ShouldEqual, 0)
+				So(opts.HeartbeatTable, ShouldEqual, "")
+
+			})
+
+			Convey("with values from config", func() {
+
+				cfg1.AddItem("mysql_max_open_conns", ctypes.ConfigValueInt{Value: 8})
+				cfg1.AddItem("mysql_max_idle_conns", ctypes.ConfigValueInt{Value: 2})
+				cfg1.AddItem("mysql_conn_max_lifetime", ctypes.ConfigValueInt{Value: 60})
+
+				sut.GetMetricTypes(cfg1)
+
+				So(opts.MaxOpenConns, ShouldEqual, 8)
+				So(opts.MaxIdleConns, ShouldEqual, 2)
+				So(opts.ConnMaxLifetime, ShouldEqual, 60*time.Second)
+
+			})
+
+			Convey("with heartbeat settings from config", func() {
+
+				cfg1.AddItem("mysql_heartbeat_table", ctypes.ConfigValueStr{Value: "percona.heartbeat"})
+				cfg1.AddItem("mysql_heartbeat_utc", ctypes.ConfigValueBool{Value: true})
+				cfg1.AddItem("mysql_heartbeat_server_id", ctypes.ConfigValueInt{Value: 12})
+
+				sut.GetMetricTypes(cfg1)
+
+				So(opts.HeartbeatTable, ShouldEqual, "percona.heartbeat")
+				So(opts.HeartbeatUTC, ShouldBeTrue)
+				So(opts.HeartbeatServerID, ShouldEqual, 12)
+
+			})
+
+			Convey("with table filters from config", func() {
+
+				cfg1.AddItem("mysql_schema_include", ctypes.ConfigValueStr{Value: "^shop"})
+				cfg1.AddItem("mysql_table_exclude", ctypes.ConfigValueStr{Value: "_tmp$"})
+
+				sut.GetMetricTypes(cfg1)
+
+				So(opts.SchemaInclude, ShouldEqual, "^shop")
+				So(opts.SchemaExclude, ShouldEqual, "")
+				So(opts.TableInclude, ShouldEqual, "")
+				So(opts.TableExclude, ShouldEqual, "_tmp$")
+
+			})
+
+			Convey("with file filters from config", func() {
+
+				cfg1.AddItem("mysql_file_include", ctypes.ConfigValueStr{Value: "ib_logfile"})
+
+				sut.GetMetricTypes(cfg1)
+
+				So(opts.FileInclude, ShouldEqual, "ib_logfile")
+				So(opts.FileExclude, ShouldEqual, "")
+
+			})
+
+		})
+
 		Convey("if initialization fails", func() {
 
 			Convey("on stats construction", func() {
 
-				makeStats = func(connectionString string) (mysqlSource, error) { return nil, errors.New("x") }
+				makeStats = func(connectionString string, opts stats.Options) (mysqlSource, error) { return nil, errors.New("x") }
 
 				_, dut_err := sut.GetMetricTypes(cfg1)
 
@@ -187,8 +356,10 @@ func TestCollectMetrics(t *testing.T) {
 
 		mocked := &collectorMock{}
 
-		makeStats = func(connectionString string) (mysqlSource, error) { return new(nullSqlsource), nil }
-		makeCollector = func(statsSource mysqlSource, useInnodb bool) collector { return mocked }
+		makeStats = func(connectionString string, opts stats.Options) (mysqlSource, error) { return new(nullSqlsource), nil }
+		makeCollector = func(statsSource mysqlSource, useInnodb bool, intervals map[int]time.Duration) collector {
+			return mocked
+		}
 
 		_, cfg2 := testingConfig()
 
@@ -210,7 +381,7 @@ func TestCollectMetrics(t *testing.T) {
 			}
 
 			mocked.On("Discover").Return([]metric{metric{Name: "aaa/bbb", Call: 1}, metric{Name: "x/y/z", Call: 2}}, nil)
-			mocked.On("Collect", mock.Anything).Return(nil, errors.New("x"))
+			mocked.On("Collect", mock.Anything, mock.Anything).Return(nil, errors.New("x"))
 
 			sut.CollectMetrics(mts)
 
@@ -222,7 +393,7 @@ func TestCollectMetrics(t *testing.T) {
 
 			var dut interface{}
 			mocked.On("Discover").Return(metrics10, nil)
-			mocked.On("Collect", mock.Anything).Return(nil, errors.New("x")).Run(func(args mock.Arguments) {
+			mocked.On("Collect", mock.Anything, mock.Anything).Return(nil, errors.New("x")).Run(func(args mock.Arguments) {
 				dut = args.Get(0)
 			})
 
@@ -244,12 +415,12 @@ func TestCollectMetrics(t *testing.T) {
 				var dut interface{}
 				*mocked = collectorMock{}
 				mocked.On("Discover").Return(metrics10, nil)
-				mocked.On("Collect", mock.Anything).Return(nil, errors.New("x")).Run(func(args mock.Arguments) {
+				mocked.On("Collect", mock.Anything, mock.Anything).Return(nil, errors.New("x")).Run(func(args mock.Arguments) {
 					dut = args.Get(0)
 				})
 
 				sut.CollectMetrics(newMts)
-				mocked.AssertCalled(t, "Collect", mock.Anything)
+				mocked.AssertCalled(t, "Collect", mock.Anything, mock.Anything)
 				So(dut.(map[int]bool)[i], ShouldBeFalse)
 
 			}
@@ -265,7 +436,7 @@ func TestCollectMetrics(t *testing.T) {
 				result[v.Name] = 100 + v.Call
 			}
 
-			mocked.On("Collect", mock.Anything).Return(result, nil)
+			mocked.On("Collect", mock.Anything, mock.Anything).Return(result, nil)
 
 			dut, _ := sut.CollectMetrics(mts10)
 
@@ -281,10 +452,86 @@ func TestCollectMetrics(t *testing.T) {
 
 		})
 
+		Convey("expands dynamic elements", func() {
+
+			mocked.On("Discover").Return([]metric{metric{Name: "replication/channel/[channel]/read_position", Call: callSlave}}, nil)
+			mocked.On("Collect", mock.Anything, mock.Anything).Return(map[string]interface{}{
+				"replication/channel/[channel=east]/read_position": 10,
+				"replication/channel/[channel=west]/read_position": 20,
+			}, nil)
+
+			ns := core.NewNamespace("intel", "mysql", "replication", "channel").
+				AddDynamicElement("channel", "").
+				AddStaticElement("read_position")
+
+			Convey("to all available values", func() {
+
+				dut, _ := sut.CollectMetrics([]plugin.MetricType{plugin.MetricType{Namespace_: ns, Config_: cfg2}})
+
+				So(dut, ShouldHaveLength, 2)
+				So(dut[0].Namespace()[4].Value, ShouldEqual, "east")
+				So(dut[0].Data(), ShouldEqual, 10)
+				So(dut[1].Namespace()[4].Value, ShouldEqual, "west")
+				So(dut[1].Data(), ShouldEqual, 20)
+
+			})
+
+			Convey("to requested value", func() {
+
+				ns[4].Value = "west"
+
+				dut, _ := sut.CollectMetrics([]plugin.MetricType{plugin.MetricType{Namespace_: ns, Config_: cfg2}})
+
+				So(dut, ShouldHaveLength, 1)
+				So(dut[0].Data(), ShouldEqual, 20)
+
+			})
+
+		})
+
+		Convey("attaches tags of collected metrics", func() {
+
+			mocked.On("Discover").Return([]metric{metric{Name: "digest/[schema]/[digest]/count", Call: callDigest}}, nil)
+			mocked.On("Collect", mock.Anything, mock.Anything).Return(map[string]interface{}{
+				"digest/[schema=shop]/[digest=abc]/count": 10.0,
+			}, nil)
+			mocked.tags = map[string]map[string]string{
+				"digest/[schema=shop]/[digest=abc]/count": {"digest_text": "SELECT * FROM `orders` WHERE `id` = ?"},
+			}
+
+			ns := core.NewNamespace("intel", "mysql", "digest").
+				AddDynamicElement("schema", "").
+				AddDynamicElement("digest", "").
+				AddStaticElement("count")
+
+			dut, _ := sut.CollectMetrics([]plugin.MetricType{plugin.MetricType{Namespace_: ns, Config_: cfg2}})
+
+			So(dut, ShouldHaveLength, 1)
+			So(dut[0].Tags()["digest_text"], ShouldEqual, "SELECT * FROM `orders` WHERE `id` = ?")
+
+		})
+
+		Convey("identifies requester by set of requested metrics", func() {
+
+			var dut1, dut2, dut3 interface{}
+			mocked.On("Discover").Return(metrics10, nil)
+			mocked.On("Collect", mock.Anything, mock.Anything).Return(nil, errors.New("x")).Run(func(args mock.Arguments) {
+				dut1, dut2, dut3 = dut2, dut3, args.Get(1)
+			})
+
+			sut.CollectMetrics(mts10[0:5])
+			sut.CollectMetrics([]plugin.MetricType{mts10[4], mts10[3], mts10[2], mts10[1], mts10[0]})
+			sut.CollectMetrics(mts10[1:6])
+
+			So(dut1, ShouldEqual, dut2)
+			So(dut1, ShouldNotEqual, dut3)
+
+		})
+
 		Convey("returns error if collection failed", func() {
 
 			mocked.On("Discover").Return(metrics10, nil)
-			mocked.On("Collect", mock.Anything).Return(nil, errors.New("x"))
+			mocked.On("Collect", mock.Anything, mock.Anything).Return(nil, errors.New("x"))
 
 			_, dut_err := sut.CollectMetrics(mts10)
 
@@ -311,3 +558,32 @@ func TestGetConfigPolicy(t *testing.T) {
 		})
 	})
 }
+
+func TestRateKey(t *testing.T) {
+	Convey("rateKey", t, func() {
+
+		cfg := cdata.NewNode()
+		mts := []plugin.MetricType{
+			plugin.MetricType{Namespace_: core.NewNamespace("intel", "mysql", "b"), Config_: cfg},
+			plugin.MetricType{Namespace_: core.NewNamespace("intel", "mysql", "a"), Config_: cfg},
+		}
+		defaultKey := rateKey(mts)
+
+		Convey("is made from set of requested metrics by default", func() {
+			So(rateKey([]plugin.MetricType{mts[1], mts[0]}), ShouldEqual, defaultKey)
+			So(rateKey(mts[:1]), ShouldNotEqual, defaultKey)
+		})
+
+		Convey("is taken from config when set", func() {
+			cfg.AddItem("mysql_rate_key", ctypes.ConfigValueStr{Value: "task1"})
+			So(rateKey(mts), ShouldEqual, "task1")
+		})
+
+		Convey("ignores config value which isn't a string", func() {
+			cfg.AddItem("mysql_rate_key", ctypes.ConfigValueInt{Value: 1})
+			So(func() { rateKey(mts) }, ShouldNotPanic)
+			So(rateKey(mts), ShouldEqual, defaultKey)
+		})
+
+	})
+}
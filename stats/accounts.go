@@ -0,0 +1,150 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+)
+
+const (
+	usersQuery    = "SELECT USER, CURRENT_CONNECTIONS, TOTAL_CONNECTIONS FROM performance_schema.users WHERE USER IS NOT NULL"
+	hostsQuery    = "SELECT HOST, CURRENT_CONNECTIONS, TOTAL_CONNECTIONS FROM performance_schema.hosts WHERE HOST IS NOT NULL"
+	accountsQuery = "SELECT USER, HOST, CURRENT_CONNECTIONS, TOTAL_CONNECTIONS FROM performance_schema.accounts WHERE USER IS NOT NULL AND HOST IS NOT NULL"
+
+	// timers are converted from picoseconds to microseconds
+	accountStatementsQuery = `SELECT USER, HOST, SUM(COUNT_STAR), SUM(SUM_TIMER_WAIT) DIV 1000000, SUM(SUM_ERRORS)
+	FROM performance_schema.events_statements_summary_by_account_by_event_name
+	WHERE USER IS NOT NULL AND HOST IS NOT NULL
+	GROUP BY USER, HOST`
+)
+
+// statementTotals holds statement statistics summed up for user, host or account.
+type statementTotals struct {
+	count, latency, errors int64
+}
+
+// GetAccounts queries database for connections and statements of each user,
+// client host and account (user and host pair).
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetAccounts() (Stats, error) {
+	stats := Stats{}
+
+	err := queryConnections(mysql.users, stats, 1, func(names []string) string {
+		return "user/" + Dynamic("user", names[0])
+	})
+	if err != nil {
+		return nil, fmt.Errorf("accounts request failed: %v", err)
+	}
+
+	err = queryConnections(mysql.hosts, stats, 1, func(names []string) string {
+		return "host/" + Dynamic("host", names[0])
+	})
+	if err != nil {
+		return nil, fmt.Errorf("accounts request failed: %v", err)
+	}
+
+	err = queryConnections(mysql.accounts, stats, 2, func(names []string) string {
+		return accountPrefix(names[0], names[1])
+	})
+	if err != nil {
+		return nil, fmt.Errorf("accounts request failed: %v", err)
+	}
+
+	rows, err := mysql.accountStatements.Query()
+	if err != nil {
+		return nil, fmt.Errorf("accounts request failed: %v", err)
+	}
+	defer rows.Close()
+
+	// totals accessible by metric name prefix of user, host and account
+	totals := map[string]*statementTotals{}
+
+	for rows.Next() {
+		var user, host string
+		var count, latency, errors interface{}
+
+		err = rows.Scan(&user, &host, &count, &latency, &errors)
+		if err != nil {
+			return nil, fmt.Errorf("accounts request failed: %v", err)
+		}
+
+		for _, prefix := range []string{"user/" + Dynamic("user", user), "host/" + Dynamic("host", host), accountPrefix(user, host)} {
+			t, ok := totals[prefix]
+			if !ok {
+				t = &statementTotals{}
+				totals[prefix] = t
+			}
+			t.count += toInt(count)
+			t.latency += toInt(latency)
+			t.errors += toInt(errors)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("accounts request failed: %v", err)
+	}
+
+	for prefix, t := range totals {
+		stats[prefix+"/statements/count"] = counter(t.count)
+		stats[prefix+"/statements/latency"] = counter(t.latency)
+		stats[prefix+"/statements/errors"] = counter(t.errors)
+	}
+
+	return stats, nil
+}
+
+// accountPrefix returns metric name prefix of account.
+func accountPrefix(user, host string) string {
+	return "account/" + Dynamic("user", user) + "/" + Dynamic("host", host)
+}
+
+// queryConnections executes query selecting names (as many as given) followed
+// by current and total number of connections and adds them to stats under
+// prefix returned by prefix function.
+func queryConnections(stmt *optionalStmt, stats Stats, names int, prefix func(names []string) string) error {
+	rows, err := stmt.Query()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values := make([]string, names)
+		var current, total interface{}
+
+		dst := []interface{}{}
+		for i := range values {
+			dst = append(dst, &values[i])
+		}
+		dst = append(dst, &current, &total)
+
+		err = rows.Scan(dst...)
+		if err != nil {
+			return err
+		}
+
+		p := prefix(values)
+		stats[p+"/connections/current"] = gauge(current)
+		stats[p+"/connections/total"] = counter(total)
+	}
+
+	return rows.Err()
+}
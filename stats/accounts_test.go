@@ -0,0 +1,97 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetAccounts(t *testing.T) {
+	Convey("GetAccounts", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		usersStmt := mock.ExpectPrepare("performance_schema.users")
+		hostsStmt := mock.ExpectPrepare("performance_schema.hosts")
+		accountsStmt := mock.ExpectPrepare("performance_schema.accounts")
+		statementsStmt := mock.ExpectPrepare("events_statements_summary_by_account_by_event_name")
+
+		usersStmt.ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"USER", "CURRENT_CONNECTIONS", "TOTAL_CONNECTIONS"}).
+				AddRow("app", 12, 3000).
+				AddRow("backup", 1, 10))
+		hostsStmt.ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"HOST", "CURRENT_CONNECTIONS", "TOTAL_CONNECTIONS"}).
+				AddRow("10.0.0.1", 8, 2000).
+				AddRow("10.0.0.2", 5, 1010))
+		accountsStmt.ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"USER", "HOST", "CURRENT_CONNECTIONS", "TOTAL_CONNECTIONS"}).
+				AddRow("app", "10.0.0.1", 8, 2000).
+				AddRow("app", "10.0.0.2", 4, 1000).
+				AddRow("backup", "10.0.0.2", 1, 10))
+		statementsStmt.ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"USER", "HOST", "SUM(COUNT_STAR)", "SUM(SUM_TIMER_WAIT) DIV 1000000", "SUM(SUM_ERRORS)"}).
+				AddRow("app", "10.0.0.1", "50000", "120000", "3").
+				AddRow("app", "10.0.0.2", "25000", "60000", "1").
+				AddRow("backup", "10.0.0.2", "100", "900000", "0"))
+
+		sut := &MySQLStats{
+			db:                db,
+			users:             prepareOptional(db, usersQuery),
+			hosts:             prepareOptional(db, hostsQuery),
+			accounts:          prepareOptional(db, accountsQuery),
+			accountStatements: prepareOptional(db, accountStatementsQuery),
+		}
+
+		dut, err := sut.GetAccounts()
+
+		Convey("reports connections of users, hosts and accounts", func() {
+
+			So(err, ShouldBeNil)
+			So(dut["user/[user=app]/connections/current"].Value, ShouldEqual, 12)
+			So(dut["user/[user=app]/connections/total"].Type, ShouldEqual, Counter)
+			So(dut["host/[host=10.0.0.2]/connections/current"].Value, ShouldEqual, 5)
+			So(dut["account/[user=app]/[host=10.0.0.2]/connections/total"].Value, ShouldEqual, 1000)
+
+		})
+
+		Convey("reports statements of accounts", func() {
+
+			So(dut["account/[user=app]/[host=10.0.0.1]/statements/count"].Value, ShouldEqual, 50000)
+			So(dut["account/[user=backup]/[host=10.0.0.2]/statements/latency"].Value, ShouldEqual, 900000)
+
+		})
+
+		Convey("reports statements of users and hosts summed up over accounts", func() {
+
+			So(dut["user/[user=app]/statements/count"].Value, ShouldEqual, 75000)
+			So(dut["user/[user=app]/statements/errors"].Value, ShouldEqual, 4)
+			So(dut["host/[host=10.0.0.2]/statements/count"].Value, ShouldEqual, 25100)
+			So(dut["host/[host=10.0.0.2]/statements/latency"].Value, ShouldEqual, 960000)
+
+		})
+
+	})
+}
@@ -0,0 +1,187 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	applierWorkersQuery    = "SELECT * FROM performance_schema.replication_applier_status_by_worker"
+	replicaConnectionQuery = "SELECT * FROM performance_schema.replication_connection_status"
+)
+
+// timestampLayout is format of performance_schema timestamps when they are not
+// parsed by driver.
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+// GetApplierWorkers queries database for state of replication applier workers
+// (which apply transactions in parallel when slave_parallel_workers > 0) and
+// of replication connection threads. Error is returned when server does not
+// replicate from any source.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetApplierWorkers() (Stats, error) {
+	rows, err := mysql.applierWorkers.Query()
+	if err != nil {
+		return nil, fmt.Errorf("applier request failed: %v", err)
+	}
+	defer rows.Close()
+
+	workers, err := scanNamedRows(rows, nil)
+	if err != nil {
+		return nil, fmt.Errorf("applier request failed: %v", err)
+	}
+
+	if len(workers) == 0 {
+		return nil, fmt.Errorf("applier request returned 0 rows")
+	}
+
+	stats := Stats{}
+
+	for _, row := range workers {
+		prefix := "replication/applier/" + Dynamic("channel", channelName(row["CHANNEL_NAME"])) +
+			"/worker/" + Dynamic("worker", toString(row["WORKER_ID"])) + "/"
+
+		for k, v := range workerStats(row) {
+			stats[prefix+k] = v
+		}
+	}
+
+	rows, err = mysql.replicaConnection.Query()
+	if err != nil {
+		return nil, fmt.Errorf("applier request failed: %v", err)
+	}
+	defer rows.Close()
+
+	connections, err := scanNamedRows(rows, nil)
+	if err != nil {
+		return nil, fmt.Errorf("applier request failed: %v", err)
+	}
+
+	for _, row := range connections {
+		prefix := "replication/applier/" + Dynamic("channel", channelName(row["CHANNEL_NAME"])) + "/connection/"
+
+		for k, v := range connectionStats(row) {
+			stats[prefix+k] = v
+		}
+	}
+
+	return stats, nil
+}
+
+// workerStats converts row of replication_applier_status_by_worker to stats.
+func workerStats(row map[string]interface{}) Stats {
+	stats := Stats{}
+
+	for col, value := range row {
+		switch col {
+		case "SERVICE_STATE":
+			stats["service_state"] = onOff(value)
+		case "LAST_ERROR_NUMBER":
+			stats["last_errno"] = gauge(value)
+		case "LAST_ERROR_MESSAGE":
+			stats["last_error"] = text(value)
+		}
+	}
+
+	// available since MySQL 8.0.1
+	if _, ok := row["LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP"]; ok {
+		stats["lag"] = timestampDiff(row["LAST_APPLIED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP"],
+			row["LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP"])
+	}
+
+	return stats
+}
+
+// connectionStats converts row of replication_connection_status to stats.
+func connectionStats(row map[string]interface{}) Stats {
+	stats := Stats{}
+
+	for col, value := range row {
+		switch col {
+		case "SERVICE_STATE":
+			stats["service_state"] = onOff(value)
+		case "LAST_ERROR_NUMBER":
+			stats["last_errno"] = gauge(value)
+		case "LAST_ERROR_MESSAGE":
+			stats["last_error"] = text(value)
+		case "COUNT_RECEIVED_HEARTBEATS":
+			stats["received_heartbeats"] = counter(value)
+		}
+	}
+
+	// available since MySQL 8.0.1
+	if _, ok := row["LAST_QUEUED_TRANSACTION_END_QUEUE_TIMESTAMP"]; ok {
+		stats["lag"] = timestampDiff(row["LAST_QUEUED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP"],
+			row["LAST_QUEUED_TRANSACTION_END_QUEUE_TIMESTAMP"])
+	}
+
+	return stats
+}
+
+// channelName returns name of replication channel used in metric names.
+func channelName(val interface{}) string {
+	if name := toString(val); name != "" {
+		return name
+	}
+	return defaultChannelName
+}
+
+// timestampDiff fills Stat structure of gauge type with number of milliseconds
+// between given timestamps. Stat is null if any of timestamps is not set.
+func timestampDiff(from, to interface{}) Stat {
+	start, ok := toTime(from)
+	if !ok {
+		return Stat{Type: Gauge, IsNull: true}
+	}
+	end, ok := toTime(to)
+	if !ok {
+		return Stat{Type: Gauge, IsNull: true}
+	}
+	return gauge(int64(end.Sub(start) / time.Millisecond))
+}
+
+// toTime converts timestamp read from database to time.Time. Zero timestamps
+// (used by performance_schema when there is no value) are reported as not set.
+func toTime(val interface{}) (time.Time, bool) {
+	var t time.Time
+
+	switch v := val.(type) {
+	case time.Time:
+		t = v
+	case nil:
+		return t, false
+	default:
+		s := toString(v)
+		if strings.HasPrefix(s, "0000-00-00") {
+			return t, false
+		}
+		var err error
+		t, err = time.Parse(timestampLayout, s)
+		if err != nil {
+			return t, false
+		}
+	}
+
+	return t, !t.IsZero()
+}
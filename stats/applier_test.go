@@ -0,0 +1,134 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetApplierWorkers(t *testing.T) {
+	Convey("GetApplierWorkers", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		workers := sqlmock.NewRows([]string{"CHANNEL_NAME", "WORKER_ID", "THREAD_ID", "SERVICE_STATE", "LAST_ERROR_NUMBER", "LAST_ERROR_MESSAGE",
+			"LAST_APPLIED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP", "LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP"})
+		connections := sqlmock.NewRows([]string{"CHANNEL_NAME", "SERVICE_STATE", "COUNT_RECEIVED_HEARTBEATS", "LAST_ERROR_NUMBER", "LAST_ERROR_MESSAGE",
+			"LAST_QUEUED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP", "LAST_QUEUED_TRANSACTION_END_QUEUE_TIMESTAMP"})
+
+		setup := func() *MySQLStats {
+			workersStmt := mock.ExpectPrepare("replication_applier_status_by_worker")
+			connectionStmt := mock.ExpectPrepare("replication_connection_status")
+			workersStmt.ExpectQuery().WillReturnRows(workers)
+			connectionStmt.ExpectQuery().WillReturnRows(connections)
+
+			return &MySQLStats{
+				db:                db,
+				applierWorkers:    prepareOptional(db, applierWorkersQuery),
+				replicaConnection: prepareOptional(db, replicaConnectionQuery),
+			}
+		}
+
+		Convey("reports state, error and lag of each worker", func() {
+
+			workers.AddRow("", 1, 50, "ON", 0, "", "2020-05-04 10:00:00.000000", "2020-05-04 10:00:01.250000").
+				AddRow("", 2, 51, "OFF", 1062, "Duplicate entry", "0000-00-00 00:00:00.000000", "0000-00-00 00:00:00.000000")
+			connections.AddRow("", "ON", 12, 0, "", "2020-05-04 10:00:02.000000", "2020-05-04 10:00:02.300000")
+
+			dut, err := setup().GetApplierWorkers()
+
+			So(err, ShouldBeNil)
+			So(dut["replication/applier/[channel=default]/worker/[worker=1]/service_state"].Value, ShouldEqual, 1)
+			So(dut["replication/applier/[channel=default]/worker/[worker=1]/lag"].Value, ShouldEqual, 1250)
+			So(dut["replication/applier/[channel=default]/worker/[worker=2]/service_state"].Value, ShouldEqual, 0)
+			So(dut["replication/applier/[channel=default]/worker/[worker=2]/last_errno"].Value, ShouldEqual, 1062)
+			So(dut["replication/applier/[channel=default]/worker/[worker=2]/last_error"].Text, ShouldEqual, "Duplicate entry")
+			So(dut["replication/applier/[channel=default]/worker/[worker=2]/lag"].IsNull, ShouldBeTrue)
+
+		})
+
+		Convey("reports state and lag of connection of each channel", func() {
+
+			workers.AddRow("europe", 1, 50, "ON", 0, "", "2020-05-04 10:00:00.000000", "2020-05-04 10:00:01.000000")
+			connections.AddRow("europe", "CONNECTING", 12, 2003, "error connecting to master", "2020-05-04 10:00:02.000000", "2020-05-04 10:00:02.300000")
+
+			dut, err := setup().GetApplierWorkers()
+
+			So(err, ShouldBeNil)
+			So(dut["replication/applier/[channel=europe]/connection/service_state"].Value, ShouldEqual, 0)
+			So(dut["replication/applier/[channel=europe]/connection/received_heartbeats"].Type, ShouldEqual, Counter)
+			So(dut["replication/applier/[channel=europe]/connection/last_errno"].Value, ShouldEqual, 2003)
+			So(dut["replication/applier/[channel=europe]/connection/lag"].Value, ShouldEqual, 300)
+
+		})
+
+		Convey("does not report lag on MySQL 5.7", func() {
+
+			workers = sqlmock.NewRows([]string{"CHANNEL_NAME", "WORKER_ID", "THREAD_ID", "SERVICE_STATE", "LAST_ERROR_NUMBER"}).
+				AddRow("", 1, 50, "ON", 0)
+			connections = sqlmock.NewRows([]string{"CHANNEL_NAME", "SERVICE_STATE"}).
+				AddRow("", "ON")
+
+			dut, err := setup().GetApplierWorkers()
+
+			So(err, ShouldBeNil)
+			So(dut, ShouldContainKey, "replication/applier/[channel=default]/worker/[worker=1]/service_state")
+			So(dut, ShouldNotContainKey, "replication/applier/[channel=default]/worker/[worker=1]/lag")
+
+		})
+
+		Convey("fails when server is not a replica", func() {
+
+			_, err := setup().GetApplierWorkers()
+
+			So(err, ShouldNotBeNil)
+
+		})
+
+	})
+}
+
+func TestToTime(t *testing.T) {
+	Convey("toTime", t, func() {
+
+		Convey("parses timestamp with microseconds", func() {
+			dut, ok := toTime([]byte("2020-05-04 10:00:01.250000"))
+			So(ok, ShouldBeTrue)
+			So(dut.Nanosecond(), ShouldEqual, 250000000)
+		})
+
+		Convey("reports zero timestamp as not set", func() {
+			_, ok := toTime([]byte("0000-00-00 00:00:00.000000"))
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("accepts time parsed by driver", func() {
+			_, ok := toTime(time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC))
+			So(ok, ShouldBeTrue)
+		})
+
+	})
+}
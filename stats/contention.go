@@ -0,0 +1,74 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contentionQuery selects synchronization instruments with the highest total
+// wait time, timers are converted from picoseconds to microseconds.
+const contentionQuery = `SELECT EVENT_NAME, COUNT_STAR, SUM_TIMER_WAIT DIV 1000000
+	FROM performance_schema.events_waits_summary_global_by_event_name
+	WHERE (EVENT_NAME LIKE 'wait/synch/mutex/%' OR EVENT_NAME LIKE 'wait/synch/rwlock/%'
+	OR EVENT_NAME LIKE 'wait/synch/sxlock/%') AND COUNT_STAR > 0
+	ORDER BY SUM_TIMER_WAIT DESC LIMIT ?`
+
+// DefaultContentionLimit is number of synchronization instruments reported
+// when Options.ContentionLimit is not set.
+const DefaultContentionLimit = 20
+
+// GetContention queries database for waits on mutexes, rw-locks and sx-locks.
+// Only instruments with the highest total wait time are reported, instrument
+// name (ex. synch/mutex/innodb/buf_pool_mutex) is a namespace element.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetContention() (Stats, error) {
+	rows, err := mysql.contention.Query(mysql.contentionLimit)
+	if err != nil {
+		return nil, fmt.Errorf("contention request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+
+	for rows.Next() {
+		var name string
+		var count, latency interface{}
+
+		err = rows.Scan(&name, &count, &latency)
+		if err != nil {
+			return nil, fmt.Errorf("contention request failed: %v", err)
+		}
+
+		event := strings.TrimPrefix(name, "wait/")
+
+		prefix := "contention/event/" + Dynamic("event", event) + "/"
+		stats[prefix+"count"] = counter(count)
+		stats[prefix+"latency"] = counter(latency)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("contention request failed: %v", err)
+	}
+
+	return stats, nil
+}
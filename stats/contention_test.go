@@ -0,0 +1,57 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetContention(t *testing.T) {
+	Convey("GetContention", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectPrepare("wait/synch/mutex").ExpectQuery().WithArgs(int64(2)).WillReturnRows(
+			sqlmock.NewRows([]string{"EVENT_NAME", "COUNT_STAR", "SUM_TIMER_WAIT"}).
+				AddRow("wait/synch/mutex/innodb/buf_pool_mutex", 12000, 3400).
+				AddRow("wait/synch/sxlock/innodb/hash_table_locks", 800, 150))
+
+		sut := &MySQLStats{db: db, contention: prepareOptional(db, contentionQuery), contentionLimit: 2}
+
+		Convey("reports waits of synchronization instruments", func() {
+
+			dut, err := sut.GetContention()
+
+			So(err, ShouldBeNil)
+			So(dut["contention/event/[event=synch_mutex_innodb_buf_pool_mutex]/count"].Value, ShouldEqual, 12000)
+			So(dut["contention/event/[event=synch_mutex_innodb_buf_pool_mutex]/count"].Type, ShouldEqual, Counter)
+			So(dut["contention/event/[event=synch_mutex_innodb_buf_pool_mutex]/latency"].Value, ShouldEqual, 3400)
+			So(dut["contention/event/[event=synch_sxlock_innodb_hash_table_locks]/count"].Value, ShouldEqual, 800)
+			So(dut["contention/event/[event=synch_sxlock_innodb_hash_table_locks]/latency"].Value, ShouldEqual, 150)
+
+		})
+
+	})
+}
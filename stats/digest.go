@@ -0,0 +1,94 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+)
+
+// digestQuery selects digests with the highest total latency, timers are
+// converted from picoseconds to microseconds.
+const digestQuery = `SELECT SCHEMA_NAME, DIGEST, DIGEST_TEXT, COUNT_STAR,
+	SUM_TIMER_WAIT DIV 1000000, AVG_TIMER_WAIT DIV 1000000,
+	SUM_ROWS_EXAMINED, SUM_ROWS_SENT, SUM_CREATED_TMP_DISK_TABLES, SUM_NO_INDEX_USED, SUM_ERRORS
+	FROM performance_schema.events_statements_summary_by_digest
+	WHERE DIGEST IS NOT NULL
+	ORDER BY SUM_TIMER_WAIT DESC LIMIT ?`
+
+// DefaultDigestLimit is number of statement digests reported when
+// Options.DigestLimit is not set.
+const DefaultDigestLimit = 20
+
+// noSchemaName is used as schema element of statements executed without
+// default database.
+const noSchemaName = "none"
+
+// digestTextTag is name of tag holding normalized statement text.
+const digestTextTag = "digest_text"
+
+// GetDigests queries database for statistics of statements grouped by their
+// digest (normalized text). Only digests with the highest total latency are
+// reported, normalized statement text is attached as a tag.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetDigests() (Stats, error) {
+	rows, err := mysql.digests.Query(mysql.digestLimit)
+	if err != nil {
+		return nil, fmt.Errorf("digest request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+
+	for rows.Next() {
+		var schema, digest, digestText interface{}
+		var count, totalLatency, avgLatency, rowsExamined, rowsSent, tmpDiskTables, noIndexUsed, errors interface{}
+
+		err = rows.Scan(&schema, &digest, &digestText, &count, &totalLatency, &avgLatency,
+			&rowsExamined, &rowsSent, &tmpDiskTables, &noIndexUsed, &errors)
+		if err != nil {
+			return nil, fmt.Errorf("digest request failed: %v", err)
+		}
+
+		schemaName := toString(schema)
+		if schemaName == "" {
+			schemaName = noSchemaName
+		}
+
+		prefix := "digest/" + Dynamic("schema", schemaName) + "/" + Dynamic("digest", toString(digest)) + "/"
+		tags := map[string]string{digestTextTag: toString(digestText)}
+
+		for k, v := range map[string]Stat{
+			"count":           counter(count),
+			"total_latency":   counter(totalLatency),
+			"avg_latency":     gauge(avgLatency),
+			"rows_examined":   counter(rowsExamined),
+			"rows_sent":       counter(rowsSent),
+			"tmp_disk_tables": counter(tmpDiskTables),
+			"no_index_used":   counter(noIndexUsed),
+			"errors":          counter(errors),
+		} {
+			v.Tags = tags
+			stats[prefix+k] = v
+		}
+	}
+
+	return stats, rows.Err()
+}
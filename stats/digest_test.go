@@ -0,0 +1,66 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetDigests(t *testing.T) {
+	Convey("GetDigests", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectPrepare("events_statements_summary_by_digest").ExpectQuery().WithArgs(int64(5)).WillReturnRows(
+			sqlmock.NewRows([]string{"SCHEMA_NAME", "DIGEST", "DIGEST_TEXT", "COUNT_STAR", "SUM_TIMER_WAIT", "AVG_TIMER_WAIT",
+				"SUM_ROWS_EXAMINED", "SUM_ROWS_SENT", "SUM_CREATED_TMP_DISK_TABLES", "SUM_NO_INDEX_USED", "SUM_ERRORS"}).
+				AddRow("shop", "abc", "SELECT * FROM `orders` WHERE `id` = ?", 100, 25000, 250, 100, 100, 0, 0, 1).
+				AddRow(nil, "def", "SELECT @@`version`", 10, 100, 10, 0, 10, 2, 10, 0))
+
+		sut := &MySQLStats{db: db, digests: prepareOptional(db, digestQuery), digestLimit: 5}
+
+		dut, err := sut.GetDigests()
+
+		Convey("reports statistics of each digest", func() {
+
+			So(err, ShouldBeNil)
+			So(dut["digest/[schema=shop]/[digest=abc]/count"].Value, ShouldEqual, 100)
+			So(dut["digest/[schema=shop]/[digest=abc]/count"].Type, ShouldEqual, Counter)
+			So(dut["digest/[schema=shop]/[digest=abc]/total_latency"].Value, ShouldEqual, 25000)
+			So(dut["digest/[schema=shop]/[digest=abc]/avg_latency"].Type, ShouldEqual, Gauge)
+			So(dut["digest/[schema=shop]/[digest=abc]/errors"].Value, ShouldEqual, 1)
+			So(dut["digest/[schema=none]/[digest=def]/tmp_disk_tables"].Value, ShouldEqual, 2)
+			So(dut["digest/[schema=none]/[digest=def]/no_index_used"].Value, ShouldEqual, 10)
+
+		})
+
+		Convey("attaches normalized text as tag", func() {
+
+			So(dut["digest/[schema=shop]/[digest=abc]/rows_examined"].Tags[digestTextTag], ShouldEqual, "SELECT * FROM `orders` WHERE `id` = ?")
+
+		})
+
+	})
+}
@@ -0,0 +1,135 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const errorsQuery = `SELECT ERROR_NUMBER, ERROR_NAME, SUM_ERROR_RAISED, SUM_ERROR_HANDLED
+	FROM performance_schema.events_errors_summary_global_by_error
+	WHERE ERROR_NAME IS NOT NULL AND SUM_ERROR_RAISED > 0`
+
+// DefaultErrorLimit is number of errors reported when Options.ErrorLimit is
+// not set.
+const DefaultErrorLimit = 20
+
+// errorCodeTag is name of tag holding numeric error code.
+const errorCodeTag = "error_code"
+
+// errorNames lists last elements of names of stats reported for each error.
+var errorNames = []string{"raised", "handled"}
+
+// errorPrefix returns common part of names of stats of given error.
+func errorPrefix(name string) string {
+	return "errors/" + Dynamic("error", name) + "/"
+}
+
+// ErrorTemplates returns names of stats of individual errors with empty
+// dynamic elements. No error may be raised yet during metric discovery, so
+// names can't be taken from stats returned by GetErrors.
+func ErrorTemplates() []string {
+	res := make([]string, 0, len(errorNames))
+	for _, name := range errorNames {
+		res = append(res, errorPrefix("")+name)
+	}
+	return res
+}
+
+// errorCount holds number of times single error was raised and handled.
+type errorCount struct {
+	number, name    string
+	raised, handled int64
+}
+
+// GetErrors queries database for number of errors raised by server. Only
+// errors raised the most times are reported, error name (ex. ER_LOCK_DEADLOCK)
+// is a namespace element and its numeric code is attached as a tag.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetErrors() (Stats, error) {
+	rows, err := mysql.errors.Query()
+	if err != nil {
+		return nil, fmt.Errorf("errors request failed: %v", err)
+	}
+	defer rows.Close()
+
+	counts := byRaised{}
+	var raised, handled int64
+
+	for rows.Next() {
+		var number int64
+		var count errorCount
+
+		err = rows.Scan(&number, &count.name, &count.raised, &count.handled)
+		if err != nil {
+			return nil, fmt.Errorf("errors request failed: %v", err)
+		}
+
+		count.number = strconv.FormatInt(number, 10)
+		raised += count.raised
+		handled += count.handled
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("errors request failed: %v", err)
+	}
+
+	stats := Stats{}
+
+	stats["errors/total/raised"] = counter(raised)
+	stats["errors/total/handled"] = counter(handled)
+
+	sort.Sort(counts)
+
+	for i, count := range counts {
+		if i >= mysql.errorLimit {
+			break
+		}
+		tags := map[string]string{errorCodeTag: count.number}
+		prefix := errorPrefix(count.name)
+
+		for k, v := range map[string]Stat{
+			"raised":  counter(count.raised),
+			"handled": counter(count.handled),
+		} {
+			v.Tags = tags
+			stats[prefix+k] = v
+		}
+	}
+
+	return stats, nil
+}
+
+// byRaised sorts errors from the most frequently raised one, errors raised
+// equally often are sorted by name.
+type byRaised []errorCount
+
+func (e byRaised) Len() int      { return len(e) }
+func (e byRaised) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e byRaised) Less(i, j int) bool {
+	if e[i].raised != e[j].raised {
+		return e[i].raised > e[j].raised
+	}
+	return e[i].name < e[j].name
+}
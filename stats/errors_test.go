@@ -0,0 +1,88 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetErrors(t *testing.T) {
+	Convey("GetErrors", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectPrepare("events_errors_summary_global_by_error").ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"ERROR_NUMBER", "ERROR_NAME", "SUM_ERROR_RAISED", "SUM_ERROR_HANDLED"}).
+				AddRow(1062, "ER_DUP_ENTRY", 40, 0).
+				AddRow(1205, "ER_LOCK_WAIT_TIMEOUT", 7, 2).
+				AddRow(1213, "ER_LOCK_DEADLOCK", 12, 3))
+
+		sut := &MySQLStats{db: db, errors: prepareOptional(db, errorsQuery), errorLimit: 2}
+
+		Convey("reports totals of all errors", func() {
+
+			dut, err := sut.GetErrors()
+
+			So(err, ShouldBeNil)
+			So(dut["errors/total/raised"].Value, ShouldEqual, 59)
+			So(dut["errors/total/handled"].Value, ShouldEqual, 5)
+
+		})
+
+		Convey("reports errors raised the most times", func() {
+
+			dut, _ := sut.GetErrors()
+
+			So(dut["errors/[error=ER_DUP_ENTRY]/raised"].Value, ShouldEqual, 40)
+			So(dut["errors/[error=ER_DUP_ENTRY]/raised"].Type, ShouldEqual, Counter)
+			So(dut["errors/[error=ER_LOCK_DEADLOCK]/raised"].Value, ShouldEqual, 12)
+			So(dut["errors/[error=ER_LOCK_DEADLOCK]/handled"].Value, ShouldEqual, 3)
+			So(dut, ShouldNotContainKey, "errors/[error=ER_LOCK_WAIT_TIMEOUT]/raised")
+
+		})
+
+		Convey("reports stats listed by templates", func() {
+
+			dut, _ := sut.GetErrors()
+
+			for _, name := range ErrorTemplates() {
+				So(name, ShouldStartWith, "errors/[error=]/")
+				suffix := strings.TrimPrefix(name, "errors/[error=]/")
+				So(dut, ShouldContainKey, "errors/[error=ER_DUP_ENTRY]/"+suffix)
+			}
+
+		})
+
+		Convey("attaches error code as a tag", func() {
+
+			dut, _ := sut.GetErrors()
+
+			So(dut["errors/[error=ER_LOCK_DEADLOCK]/raised"].Tags[errorCodeTag], ShouldEqual, "1213")
+
+		})
+
+	})
+}
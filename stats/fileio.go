@@ -0,0 +1,208 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sync"
+)
+
+// fileIOSelect selects file I/O statistics in order of fileIOColumns, timers
+// are converted from picoseconds to microseconds.
+const fileIOSelect = `COUNT_READ, SUM_TIMER_READ DIV 1000000, SUM_NUMBER_OF_BYTES_READ,
+	COUNT_WRITE, SUM_TIMER_WRITE DIV 1000000, SUM_NUMBER_OF_BYTES_WRITE,
+	COUNT_MISC, SUM_TIMER_MISC DIV 1000000`
+
+const (
+	fileEventsQuery    = "SELECT EVENT_NAME, " + fileIOSelect + " FROM performance_schema.file_summary_by_event_name WHERE EVENT_NAME LIKE 'wait/io/file/%'"
+	fileUndoQuery      = "SELECT FILE_NAME, " + fileIOSelect + " FROM performance_schema.file_summary_by_instance WHERE EVENT_NAME = 'wait/io/file/innodb/innodb_data_file' AND (FILE_NAME LIKE '%undo%' OR FILE_NAME LIKE '%.ibu')"
+	fileInstancesQuery = "SELECT FILE_NAME, " + fileIOSelect + " FROM performance_schema.file_summary_by_instance"
+)
+
+// fileIOColumns lists metric names of columns selected by fileIOSelect.
+var fileIOColumns = []string{"reads", "read_latency", "read_bytes", "writes", "write_latency", "write_bytes", "misc", "misc_latency"}
+
+// fileTypes maps file I/O instruments to types of files reported. Undo
+// tablespaces are instrumented as data files, they are recognized by name and
+// excluded from data files.
+var fileTypes = map[string]string{
+	"wait/io/file/innodb/innodb_log_file":  "redo_log",
+	"wait/io/file/innodb/innodb_data_file": "data",
+	"wait/io/file/myisam/dfile":            "data",
+	"wait/io/file/myisam/kfile":            "data",
+	"wait/io/file/sql/binlog":              "binlog",
+	"wait/io/file/sql/relaylog":            "relay_log",
+}
+
+// undoFileRegexp matches names of undo tablespace files, ex. undo001 (5.7),
+// undo_001 or custom.ibu (8.0).
+var undoFileRegexp = regexp.MustCompile(`^undo_?[0-9]+$|\.ibu$`)
+
+// fileCounters sums file I/O statistics of files which may be recreated, ex.
+// undo tablespaces truncated by innodb_undo_log_truncate. Statistics of
+// recreated file start from zero, so only increases of statistics of each
+// file are added and sums never drop.
+type fileCounters struct {
+	mutex sync.Mutex
+	// the latest statistics of each file
+	last map[string][]int64
+	sums []int64
+}
+
+// update records current statistics of files and returns sums of statistics
+// since the first update, nil is returned until any file is seen. Statistics
+// lower than recorded ones are considered counted since file was recreated
+// and added in full.
+func (fc *fileCounters) update(files map[string][]int64) []int64 {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	if fc.sums == nil {
+		if len(files) == 0 {
+			return nil
+		}
+		fc.sums = make([]int64, len(fileIOColumns))
+	}
+
+	for file, values := range files {
+		last, ok := fc.last[file]
+		for i, v := range values {
+			if ok && v >= last[i] {
+				fc.sums[i] += v - last[i]
+			} else {
+				fc.sums[i] += v
+			}
+		}
+	}
+	fc.last = files
+
+	res := make([]int64, len(fc.sums))
+	copy(res, fc.sums)
+	return res
+}
+
+// GetFileIO queries database for file I/O statistics of redo log, undo log,
+// binary log, relay log and data files. Statistics of individual files are
+// reported as well when file filter selects them.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetFileIO() (Stats, error) {
+	// sums of statistics accessible by file type
+	sums := map[string][]int64{}
+
+	add := func(fileType string, values []interface{}) {
+		if _, ok := sums[fileType]; !ok {
+			sums[fileType] = make([]int64, len(fileIOColumns))
+		}
+		for i, v := range values {
+			sums[fileType][i] += toInt(v)
+		}
+	}
+
+	err := mysql.queryFileIO(mysql.fileEvents, func(event string, values []interface{}) {
+		if fileType, ok := fileTypes[event]; ok {
+			add(fileType, values)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("file io request failed: %v", err)
+	}
+
+	undoFiles := map[string][]int64{}
+	err = mysql.queryFileIO(mysql.fileUndo, func(file string, values []interface{}) {
+		if !undoFileRegexp.MatchString(path.Base(file)) {
+			return
+		}
+		undoFiles[file] = make([]int64, len(values))
+		for i, v := range values {
+			undoFiles[file][i] = toInt(v)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("file io request failed: %v", err)
+	}
+
+	// statistics of data file instrument include undo tablespaces, also the
+	// ones which were recreated
+	if undo := mysql.undoIO.update(undoFiles); undo != nil {
+		sums["undo_log"] = undo
+		if data, ok := sums["data"]; ok {
+			for i := range data {
+				data[i] -= undo[i]
+			}
+		}
+	}
+
+	stats := Stats{}
+
+	for fileType, values := range sums {
+		for i, v := range values {
+			stats["file_io/"+fileType+"/"+fileIOColumns[i]] = counter(v)
+		}
+	}
+
+	if mysql.files != nil {
+		err = mysql.queryFileIO(mysql.fileInstances, func(file string, values []interface{}) {
+			if !mysql.files.match(file) {
+				return
+			}
+			prefix := "file_io/file/" + Dynamic("file", file) + "/"
+			for i, v := range values {
+				stats[prefix+fileIOColumns[i]] = counter(v)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("file io request failed: %v", err)
+		}
+	}
+
+	return stats, nil
+}
+
+// queryFileIO executes query selecting name and file I/O statistics, handle
+// is called for each row.
+func (mysql *MySQLStats) queryFileIO(stmt *optionalStmt, handle func(name string, values []interface{})) error {
+	rows, err := stmt.Query()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		values := make([]interface{}, len(fileIOColumns))
+
+		dst := []interface{}{&name}
+		for i := range values {
+			dst = append(dst, &values[i])
+		}
+
+		err = rows.Scan(dst...)
+		if err != nil {
+			return err
+		}
+
+		handle(name, values)
+	}
+
+	return rows.Err()
+}
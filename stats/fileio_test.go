@@ -0,0 +1,138 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetFileIO(t *testing.T) {
+	Convey("GetFileIO", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		columns := []string{"NAME", "COUNT_READ", "SUM_TIMER_READ", "SUM_NUMBER_OF_BYTES_READ",
+			"COUNT_WRITE", "SUM_TIMER_WRITE", "SUM_NUMBER_OF_BYTES_WRITE", "COUNT_MISC", "SUM_TIMER_MISC"}
+
+		eventsStmt := mock.ExpectPrepare("file_summary_by_event_name")
+		undoStmt := mock.ExpectPrepare("file_summary_by_instance WHERE")
+		instancesStmt := mock.ExpectPrepare("file_summary_by_instance")
+
+		eventsStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("wait/io/file/innodb/innodb_log_file", 10, 100, 4096, 500, 2000, 1048576, 500, 9000).
+			AddRow("wait/io/file/innodb/innodb_data_file", 300, 3000, 4915200, 200, 1000, 3276800, 50, 500).
+			AddRow("wait/io/file/sql/binlog", 0, 0, 0, 400, 800, 204800, 40, 400).
+			AddRow("wait/io/file/sql/relaylog", 20, 40, 10240, 20, 40, 10240, 2, 4).
+			AddRow("wait/io/file/sql/FRM", 5, 5, 500, 0, 0, 0, 0, 0))
+		undoStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("/var/lib/mysql/undo_001", 10, 10, 163840, 20, 20, 327680, 1, 1).
+			AddRow("/var/lib/mysql/undo_002", 5, 5, 81920, 10, 10, 163840, 1, 1).
+			AddRow("/var/lib/undo_data/shop/orders.ibd", 100, 100, 1638400, 100, 100, 1638400, 1, 1))
+		instancesStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("/var/lib/mysql/ib_logfile0", 10, 100, 4096, 500, 2000, 1048576, 500, 9000).
+			AddRow("/var/lib/mysql/shop/orders.ibd", 100, 100, 1638400, 100, 100, 1638400, 1, 1))
+
+		sut := &MySQLStats{
+			db:            db,
+			fileEvents:    prepareOptional(db, fileEventsQuery),
+			fileUndo:      prepareOptional(db, fileUndoQuery),
+			fileInstances: prepareOptional(db, fileInstancesQuery),
+		}
+
+		Convey("reports statistics of each file type", func() {
+
+			dut, err := sut.GetFileIO()
+
+			So(err, ShouldBeNil)
+			So(dut["file_io/redo_log/writes"].Value, ShouldEqual, 500)
+			So(dut["file_io/redo_log/writes"].Type, ShouldEqual, Counter)
+			So(dut["file_io/redo_log/write_bytes"].Value, ShouldEqual, 1048576)
+			So(dut["file_io/redo_log/misc_latency"].Value, ShouldEqual, 9000)
+			So(dut["file_io/data/read_bytes"].Value, ShouldEqual, 4915200-163840-81920)
+			So(dut["file_io/binlog/write_latency"].Value, ShouldEqual, 800)
+			So(dut["file_io/relay_log/reads"].Value, ShouldEqual, 20)
+			So(dut, ShouldNotContainKey, "file_io/FRM/reads")
+
+		})
+
+		Convey("reports statistics of undo tablespaces", func() {
+
+			dut, _ := sut.GetFileIO()
+
+			So(dut["file_io/undo_log/reads"].Value, ShouldEqual, 15)
+			So(dut["file_io/undo_log/write_bytes"].Value, ShouldEqual, 491520)
+
+			Convey("excluded from data files", func() {
+				So(dut["file_io/data/reads"].Value, ShouldEqual, 300-15)
+				So(dut["file_io/data/write_bytes"].Value, ShouldEqual, 3276800-491520)
+			})
+
+		})
+
+		Convey("keeps undo and data statistics growing when undo tablespace is truncated", func() {
+
+			// queries of individual files are expected as well
+			sut.files, _ = newFileFilter("ib_logfile", "")
+			sut.GetFileIO()
+
+			eventsStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("wait/io/file/innodb/innodb_data_file", 320, 3200, 5242880, 210, 1100, 3440640, 60, 600))
+			undoStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("/var/lib/mysql/undo_001", 2, 2, 32768, 4, 4, 65536, 1, 1).
+				AddRow("/var/lib/mysql/undo_002", 8, 8, 131072, 12, 12, 196608, 1, 1))
+			instancesStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns))
+
+			dut, err := sut.GetFileIO()
+
+			So(err, ShouldBeNil)
+			// undo_001 was recreated, all its reads happened since then
+			So(dut["file_io/undo_log/reads"].Value, ShouldEqual, 15+2+3)
+			So(dut["file_io/undo_log/writes"].Value, ShouldEqual, 30+4+2)
+			So(dut["file_io/data/reads"].Value, ShouldEqual, 320-20)
+			So(dut["file_io/data/writes"].Value, ShouldEqual, 210-36)
+
+		})
+
+		Convey("does not report individual files without filter", func() {
+
+			dut, _ := sut.GetFileIO()
+
+			So(dut, ShouldNotContainKey, "file_io/file/[file=_var_lib_mysql_ib_logfile0]/writes")
+
+		})
+
+		Convey("reports individual files selected by filter", func() {
+
+			sut.files, _ = newFileFilter("ib_logfile", "")
+
+			dut, _ := sut.GetFileIO()
+
+			So(dut["file_io/file/[file=_var_lib_mysql_ib_logfile0]/writes"].Value, ShouldEqual, 500)
+			So(dut, ShouldNotContainKey, "file_io/file/[file=_var_lib_mysql_shop_orders.ibd]/writes")
+
+		})
+
+	})
+}
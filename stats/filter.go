@@ -0,0 +1,115 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// tableFilter selects tables which are reported by per-table calls, so number
+// of metrics stays under control on servers with many tables. Nil regular
+// expressions don't restrict selection.
+type tableFilter struct {
+	schemaInclude, schemaExclude *regexp.Regexp
+	tableInclude, tableExclude   *regexp.Regexp
+}
+
+// newTableFilter compiles regular expressions of filter, empty expressions
+// are ignored.
+func newTableFilter(schemaInclude, schemaExclude, tableInclude, tableExclude string) (*tableFilter, error) {
+	res := &tableFilter{}
+
+	for _, re := range []struct {
+		dst  **regexp.Regexp
+		expr string
+	}{
+		{&res.schemaInclude, schemaInclude},
+		{&res.schemaExclude, schemaExclude},
+		{&res.tableInclude, tableInclude},
+		{&res.tableExclude, tableExclude},
+	} {
+		if re.expr == "" {
+			continue
+		}
+		compiled, err := regexp.Compile(re.expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid table filter %q: %v", re.expr, err)
+		}
+		*re.dst = compiled
+	}
+
+	return res, nil
+}
+
+// match checks if table of given schema is selected by filter.
+func (f *tableFilter) match(schema, table string) bool {
+	if f == nil {
+		return true
+	}
+	if f.schemaInclude != nil && !f.schemaInclude.MatchString(schema) {
+		return false
+	}
+	if f.schemaExclude != nil && f.schemaExclude.MatchString(schema) {
+		return false
+	}
+	if f.tableInclude != nil && !f.tableInclude.MatchString(table) {
+		return false
+	}
+	if f.tableExclude != nil && f.tableExclude.MatchString(table) {
+		return false
+	}
+	return true
+}
+
+// fileFilter selects files reported individually by their path.
+type fileFilter struct {
+	include, exclude *regexp.Regexp
+}
+
+// newFileFilter compiles regular expressions of filter. Nil filter is
+// returned when include expression is empty, as no files are selected then.
+func newFileFilter(include, exclude string) (*fileFilter, error) {
+	if include == "" {
+		return nil, nil
+	}
+
+	res := &fileFilter{}
+
+	var err error
+	res.include, err = regexp.Compile(include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file filter %q: %v", include, err)
+	}
+
+	if exclude != "" {
+		res.exclude, err = regexp.Compile(exclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file filter %q: %v", exclude, err)
+		}
+	}
+
+	return res, nil
+}
+
+// match checks if file of given path is selected by filter.
+func (f *fileFilter) match(file string) bool {
+	return f.include.MatchString(file) && (f.exclude == nil || !f.exclude.MatchString(file))
+}
@@ -0,0 +1,89 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTableFilter(t *testing.T) {
+	Convey("tableFilter", t, func() {
+
+		Convey("selects all tables when expressions are empty", func() {
+			sut, err := newTableFilter("", "", "", "")
+			So(err, ShouldBeNil)
+			So(sut.match("shop", "orders"), ShouldBeTrue)
+		})
+
+		Convey("selects tables of included schemas", func() {
+			sut, _ := newTableFilter("^shop", "", "", "")
+			So(sut.match("shop_eu", "orders"), ShouldBeTrue)
+			So(sut.match("mysql", "user"), ShouldBeFalse)
+		})
+
+		Convey("skips tables of excluded schemas", func() {
+			sut, _ := newTableFilter("", "^(mysql|sys)$", "", "")
+			So(sut.match("shop", "orders"), ShouldBeTrue)
+			So(sut.match("sys", "sys_config"), ShouldBeFalse)
+		})
+
+		Convey("filters tables by name", func() {
+			sut, _ := newTableFilter("", "", "^order", "_tmp$")
+			So(sut.match("shop", "orders"), ShouldBeTrue)
+			So(sut.match("shop", "orders_tmp"), ShouldBeFalse)
+			So(sut.match("shop", "customers"), ShouldBeFalse)
+		})
+
+		Convey("rejects invalid expression", func() {
+			_, err := newTableFilter("(", "", "", "")
+			So(err, ShouldNotBeNil)
+		})
+
+	})
+}
+
+func TestFileFilter(t *testing.T) {
+	Convey("fileFilter", t, func() {
+
+		Convey("is not created when include expression is empty", func() {
+			sut, err := newFileFilter("", "tmp")
+			So(err, ShouldBeNil)
+			So(sut, ShouldBeNil)
+		})
+
+		Convey("selects included files which are not excluded", func() {
+			sut, err := newFileFilter("^/var/lib/mysql/", "/tmp/")
+			So(err, ShouldBeNil)
+			So(sut.match("/var/lib/mysql/ib_logfile0"), ShouldBeTrue)
+			So(sut.match("/var/lib/mysql/tmp/x.ibd"), ShouldBeFalse)
+			So(sut.match("/tmp/ibtmp1"), ShouldBeFalse)
+		})
+
+		Convey("rejects invalid expression", func() {
+			_, err := newFileFilter("[", "")
+			So(err, ShouldNotBeNil)
+		})
+
+	})
+}
@@ -0,0 +1,139 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	galeraEnabledQuery = "SELECT @@global.wsrep_on"
+	galeraStatusQuery  = "SHOW GLOBAL STATUS LIKE 'wsrep_%'"
+)
+
+// GetGalera queries database for state of Galera cluster (MariaDB Galera
+// Cluster, Percona XtraDB Cluster). Error is returned when wsrep replication
+// is not enabled on server.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetGalera() (Stats, error) {
+	rows, err := mysql.galeraEnabled.Query()
+	if err != nil {
+		return nil, fmt.Errorf("galera request failed: %v", err)
+	}
+
+	var enabled interface{}
+	if rows.Next() {
+		err = rows.Scan(&enabled)
+	}
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("galera request failed: %v", err)
+	}
+
+	if on := toString(enabled); on != "1" && !strings.EqualFold(on, "ON") {
+		return nil, fmt.Errorf("galera replication is not enabled")
+	}
+
+	rows, err = mysql.galeraStatus.Query()
+	if err != nil {
+		return nil, fmt.Errorf("galera request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+
+	for rows.Next() {
+		var name string
+		var value interface{}
+
+		err = rows.Scan(&name, &value)
+		if err != nil {
+			return nil, fmt.Errorf("galera request failed: %v", err)
+		}
+
+		switch name {
+		case "wsrep_cluster_size":
+			stats["galera/cluster_size"] = gauge(value)
+		case "wsrep_cluster_status":
+			stats["galera/cluster_status"] = text(value)
+			if strings.EqualFold(toString(value), "Primary") {
+				stats["galera/primary"] = gauge(1)
+			} else {
+				stats["galera/primary"] = gauge(0)
+			}
+		case "wsrep_local_state":
+			stats["galera/local_state"] = gauge(value)
+		case "wsrep_local_state_comment":
+			stats["galera/local_state_comment"] = text(value)
+		case "wsrep_ready":
+			stats["galera/ready"] = onOff(value)
+		case "wsrep_connected":
+			stats["galera/connected"] = onOff(value)
+
+		case "wsrep_flow_control_paused":
+			stats["galera/flow_control/paused_ppm"] = fractionPpm(value)
+		case "wsrep_flow_control_paused_ns":
+			stats["galera/flow_control/paused_ns"] = counter(value)
+		case "wsrep_flow_control_sent":
+			stats["galera/flow_control/sent"] = counter(value)
+		case "wsrep_flow_control_recv":
+			stats["galera/flow_control/recv"] = counter(value)
+
+		case "wsrep_local_recv_queue":
+			stats["galera/recv_queue"] = gauge(value)
+		case "wsrep_local_send_queue":
+			stats["galera/send_queue"] = gauge(value)
+
+		case "wsrep_local_cert_failures":
+			stats["galera/cert_failures"] = counter(value)
+		case "wsrep_local_bf_aborts":
+			stats["galera/bf_aborts"] = counter(value)
+
+		case "wsrep_replicated":
+			stats["galera/replicated"] = counter(value)
+		case "wsrep_replicated_bytes":
+			stats["galera/replicated_bytes"] = counter(value)
+		case "wsrep_received":
+			stats["galera/received"] = counter(value)
+		case "wsrep_received_bytes":
+			stats["galera/received_bytes"] = counter(value)
+		}
+	}
+
+	return stats, rows.Err()
+}
+
+// fractionPpm fills Stat structure of gauge type with fraction (value between
+// 0 and 1) expressed in parts per million.
+func fractionPpm(val interface{}) Stat {
+	if val == nil {
+		return Stat{Type: Gauge, IsNull: true}
+	}
+
+	f, err := strconv.ParseFloat(toString(val), 64)
+	if err != nil {
+		return Stat{Type: Gauge, IsNull: true}
+	}
+
+	return gauge(int64(f * 1e6))
+}
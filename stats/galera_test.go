@@ -0,0 +1,109 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetGalera(t *testing.T) {
+	Convey("GetGalera", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		setup := func(enabled string) *MySQLStats {
+			enabledStmt := mock.ExpectPrepare("wsrep_on")
+			statusStmt := mock.ExpectPrepare("SHOW GLOBAL STATUS LIKE 'wsrep_%'")
+			enabledStmt.ExpectQuery().WillReturnRows(
+				sqlmock.NewRows([]string{"@@global.wsrep_on"}).AddRow(enabled))
+			statusStmt.ExpectQuery().WillReturnRows(
+				sqlmock.NewRows([]string{"Variable_name", "Value"}).
+					AddRow("wsrep_cluster_size", "3").
+					AddRow("wsrep_cluster_status", "Primary").
+					AddRow("wsrep_local_state", "4").
+					AddRow("wsrep_local_state_comment", "Synced").
+					AddRow("wsrep_flow_control_paused", "0.012345").
+					AddRow("wsrep_local_recv_queue", "2").
+					AddRow("wsrep_local_send_queue", "0").
+					AddRow("wsrep_local_cert_failures", "17").
+					AddRow("wsrep_replicated_bytes", "123456"))
+
+			return &MySQLStats{
+				db:            db,
+				galeraEnabled: prepareOptional(db, galeraEnabledQuery),
+				galeraStatus:  prepareOptional(db, galeraStatusQuery),
+			}
+		}
+
+		Convey("reports cluster status", func() {
+
+			dut, err := setup("1").GetGalera()
+
+			So(err, ShouldBeNil)
+			So(dut["galera/cluster_size"].Value, ShouldEqual, 3)
+			So(dut["galera/cluster_status"].Text, ShouldEqual, "Primary")
+			So(dut["galera/primary"].Value, ShouldEqual, 1)
+			So(dut["galera/local_state"].Value, ShouldEqual, 4)
+			So(dut["galera/local_state_comment"].Text, ShouldEqual, "Synced")
+
+		})
+
+		Convey("reports flow control, queues and replication counters", func() {
+
+			dut, err := setup("ON").GetGalera()
+
+			So(err, ShouldBeNil)
+			So(dut["galera/flow_control/paused_ppm"].Value, ShouldEqual, 12345)
+			So(dut["galera/recv_queue"].Value, ShouldEqual, 2)
+			So(dut["galera/send_queue"].Value, ShouldEqual, 0)
+			So(dut["galera/cert_failures"].Type, ShouldEqual, Counter)
+			So(dut["galera/cert_failures"].Value, ShouldEqual, 17)
+			So(dut["galera/replicated_bytes"].Value, ShouldEqual, 123456)
+
+		})
+
+		Convey("fails when wsrep is not enabled", func() {
+
+			_, err := setup("0").GetGalera()
+
+			So(err, ShouldNotBeNil)
+
+		})
+
+		Convey("fails when server has no Galera support", func() {
+
+			mock.ExpectPrepare("wsrep_on").WillReturnError(fmt.Errorf("unknown system variable 'wsrep_on'"))
+
+			sut := &MySQLStats{db: db, galeraEnabled: prepareOptional(db, galeraEnabledQuery)}
+
+			_, err := sut.GetGalera()
+
+			So(err, ShouldNotBeNil)
+
+		})
+
+	})
+}
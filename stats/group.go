@@ -0,0 +1,166 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	groupMembersQuery     = "SELECT *, MEMBER_ID = @@server_uuid AS IS_LOCAL FROM performance_schema.replication_group_members"
+	groupMemberStatsQuery = "SELECT * FROM performance_schema.replication_group_member_stats WHERE MEMBER_ID = @@server_uuid"
+	groupFlowControlQuery = "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.global_status WHERE VARIABLE_NAME LIKE 'Gr_flow_control_%'"
+)
+
+// groupMemberStates lists states of group members which are counted
+// separately.
+var groupMemberStates = []string{"ONLINE", "RECOVERING", "OFFLINE", "ERROR", "UNREACHABLE"}
+
+// GetGroupReplication queries database for state of Group Replication
+// (InnoDB Cluster) as seen by this member. Error is returned when this server
+// is not an active member of a group.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetGroupReplication() (Stats, error) {
+	rows, err := mysql.groupMembers.Query()
+	if err != nil {
+		return nil, fmt.Errorf("group replication request failed: %v", err)
+	}
+	defer rows.Close()
+
+	members, err := scanNamedRows(rows, nil)
+	if err != nil {
+		return nil, fmt.Errorf("group replication request failed: %v", err)
+	}
+
+	stats := Stats{}
+
+	var local map[string]interface{}
+	states := map[string]int{}
+
+	for _, member := range members {
+		if toInt(member["IS_LOCAL"]) == 1 {
+			local = member
+		}
+		states[strings.ToUpper(toString(member["MEMBER_STATE"]))]++
+	}
+
+	if local == nil || states["OFFLINE"] == len(members) {
+		return nil, fmt.Errorf("group replication is not active")
+	}
+
+	stats["replication/group/members/total"] = gauge(len(members))
+	for _, state := range groupMemberStates {
+		stats["replication/group/members/"+strings.ToLower(state)] = gauge(states[state])
+	}
+
+	stats["replication/group/state"] = text(local["MEMBER_STATE"])
+
+	// MEMBER_ROLE is available since MySQL 8.0.2
+	if role, ok := local["MEMBER_ROLE"]; ok {
+		stats["replication/group/role"] = text(role)
+		if strings.EqualFold(toString(role), "PRIMARY") {
+			stats["replication/group/primary"] = gauge(1)
+		} else {
+			stats["replication/group/primary"] = gauge(0)
+		}
+	}
+
+	err = mysql.groupMemberStats(stats)
+	if err != nil {
+		return nil, fmt.Errorf("group replication request failed: %v", err)
+	}
+
+	// flow control status variables are available since MySQL 8.0.30
+	mysql.groupFlowControlStats(stats)
+
+	return stats, nil
+}
+
+// groupMemberStats adds certification and applier statistics of this member
+// to stats.
+func (mysql *MySQLStats) groupMemberStats(stats Stats) error {
+	rows, err := mysql.groupStats.Query()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	res, err := scanNamedRows(rows, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(res) == 0 {
+		return fmt.Errorf("member stats not found")
+	}
+
+	for col, value := range res[0] {
+		switch col {
+		case "COUNT_TRANSACTIONS_IN_QUEUE":
+			stats["replication/group/certification_queue"] = gauge(value)
+		case "COUNT_TRANSACTIONS_REMOTE_IN_APPLIER_QUEUE":
+			stats["replication/group/applier_queue"] = gauge(value)
+		case "COUNT_TRANSACTIONS_CHECKED":
+			stats["replication/group/transactions_checked"] = counter(value)
+		case "COUNT_CONFLICTS_DETECTED":
+			stats["replication/group/conflicts_detected"] = counter(value)
+		case "COUNT_TRANSACTIONS_ROWS_VALIDATING":
+			stats["replication/group/rows_validating"] = gauge(value)
+		case "COUNT_TRANSACTIONS_REMOTE_APPLIED":
+			stats["replication/group/remote_applied"] = counter(value)
+		case "COUNT_TRANSACTIONS_LOCAL_PROPOSED":
+			stats["replication/group/local_proposed"] = counter(value)
+		case "COUNT_TRANSACTIONS_LOCAL_ROLLBACK":
+			stats["replication/group/local_rollback"] = counter(value)
+		}
+	}
+
+	return nil
+}
+
+// groupFlowControlStats adds flow control statistics to stats. They are
+// skipped if they can't be read.
+func (mysql *MySQLStats) groupFlowControlStats(stats Stats) {
+	rows, err := mysql.groupFlowControl.Query()
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var value interface{}
+
+		if rows.Scan(&name, &value) != nil {
+			return
+		}
+
+		switch name {
+		case "Gr_flow_control_throttle_count":
+			stats["replication/group/flow_control/throttle_count"] = counter(value)
+		case "Gr_flow_control_throttle_time_sum":
+			stats["replication/group/flow_control/throttle_time"] = counter(value)
+		case "Gr_flow_control_throttle_active_count":
+			stats["replication/group/flow_control/throttle_active"] = gauge(value)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetGroupReplication(t *testing.T) {
+	Convey("GetGroupReplication", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		members := sqlmock.NewRows([]string{"CHANNEL_NAME", "MEMBER_ID", "MEMBER_STATE", "MEMBER_ROLE", "IS_LOCAL"})
+		memberStats := sqlmock.NewRows([]string{"MEMBER_ID", "COUNT_TRANSACTIONS_IN_QUEUE", "COUNT_TRANSACTIONS_CHECKED",
+			"COUNT_CONFLICTS_DETECTED", "COUNT_TRANSACTIONS_REMOTE_IN_APPLIER_QUEUE"}).
+			AddRow(uuid1, 3, 1200, 7, 12)
+		flowControl := sqlmock.NewRows([]string{"VARIABLE_NAME", "VARIABLE_VALUE"}).
+			AddRow("Gr_flow_control_throttle_count", "5")
+
+		setup := func() *MySQLStats {
+			membersStmt := mock.ExpectPrepare("replication_group_members")
+			statsStmt := mock.ExpectPrepare("replication_group_member_stats")
+			flowControlStmt := mock.ExpectPrepare("Gr_flow_control")
+			membersStmt.ExpectQuery().WillReturnRows(members)
+			statsStmt.ExpectQuery().WillReturnRows(memberStats)
+			flowControlStmt.ExpectQuery().WillReturnRows(flowControl)
+
+			return &MySQLStats{
+				db:               db,
+				groupMembers:     prepareOptional(db, groupMembersQuery),
+				groupStats:       prepareOptional(db, groupMemberStatsQuery),
+				groupFlowControl: prepareOptional(db, groupFlowControlQuery),
+			}
+		}
+
+		Convey("reports members and this member's role", func() {
+
+			members.AddRow("group_replication_applier", uuid1, "ONLINE", "PRIMARY", 1).
+				AddRow("group_replication_applier", uuid2, "ONLINE", "SECONDARY", 0).
+				AddRow("group_replication_applier", "f2e3a1c4-0000-11e1-9e33-c80aa9429562", "RECOVERING", "SECONDARY", 0)
+
+			dut, err := setup().GetGroupReplication()
+
+			So(err, ShouldBeNil)
+			So(dut["replication/group/members/total"].Value, ShouldEqual, 3)
+			So(dut["replication/group/members/online"].Value, ShouldEqual, 2)
+			So(dut["replication/group/members/recovering"].Value, ShouldEqual, 1)
+			So(dut["replication/group/members/unreachable"].Value, ShouldEqual, 0)
+			So(dut["replication/group/role"].Text, ShouldEqual, "PRIMARY")
+			So(dut["replication/group/primary"].Value, ShouldEqual, 1)
+			So(dut["replication/group/state"].Text, ShouldEqual, "ONLINE")
+
+		})
+
+		Convey("reports queues, conflicts and flow control", func() {
+
+			members.AddRow("group_replication_applier", uuid1, "ONLINE", "PRIMARY", 1)
+
+			dut, err := setup().GetGroupReplication()
+
+			So(err, ShouldBeNil)
+			So(dut["replication/group/certification_queue"].Value, ShouldEqual, 3)
+			So(dut["replication/group/applier_queue"].Value, ShouldEqual, 12)
+			So(dut["replication/group/transactions_checked"].Type, ShouldEqual, Counter)
+			So(dut["replication/group/conflicts_detected"].Value, ShouldEqual, 7)
+			So(dut["replication/group/flow_control/throttle_count"].Value, ShouldEqual, 5)
+
+		})
+
+		Convey("skips flow control when it can't be read", func() {
+
+			members.AddRow("group_replication_applier", uuid1, "ONLINE", "PRIMARY", 1)
+
+			sut := setup()
+			sut.groupFlowControl = nil
+
+			dut, err := sut.GetGroupReplication()
+
+			So(err, ShouldBeNil)
+			So(dut, ShouldNotContainKey, "replication/group/flow_control/throttle_count")
+
+		})
+
+		Convey("fails when group replication is not active", func() {
+
+			members.AddRow("group_replication_applier", uuid1, "OFFLINE", "", 1)
+
+			_, err := setup().GetGroupReplication()
+
+			So(err, ShouldNotBeNil)
+
+		})
+
+		Convey("fails when group replication is not supported", func() {
+
+			sut := &MySQLStats{db: db}
+
+			_, err := sut.GetGroupReplication()
+
+			So(err, ShouldNotBeNil)
+			So(fmt.Sprint(err), ShouldContainSubstring, "group replication request failed")
+
+		})
+
+	})
+}
@@ -0,0 +1,123 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gtidInterval is inclusive range of transaction numbers.
+type gtidInterval struct {
+	start, end int64
+}
+
+// gtidSet holds intervals of transactions accessible by source UUID.
+type gtidSet map[string][]gtidInterval
+
+// parseGtidSet parses MySQL GTID set, ex. "uuid1:1-5:7,uuid2:1-10".
+// Tags of tagged GTIDs (MySQL 8.3+) are ignored, so their transactions are
+// accounted to the source UUID.
+func parseGtidSet(s string) (gtidSet, error) {
+	res := gtidSet{}
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid gtid set item: %s", item)
+		}
+
+		uuid := strings.ToLower(parts[0])
+
+		for _, part := range parts[1:] {
+			bounds := strings.SplitN(part, "-", 2)
+
+			start, err := strconv.ParseInt(bounds[0], 10, 64)
+			if err != nil {
+				// tag of tagged gtid
+				continue
+			}
+
+			end := start
+			if len(bounds) == 2 {
+				end, err = strconv.ParseInt(bounds[1], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid gtid interval: %s", part)
+				}
+			}
+
+			res[uuid] = append(res[uuid], gtidInterval{start: start, end: end})
+		}
+	}
+
+	return res, nil
+}
+
+// counts returns number of transactions per source UUID.
+func (gs gtidSet) counts() map[string]int64 {
+	res := map[string]int64{}
+	for uuid, intervals := range gs {
+		for _, i := range intervals {
+			res[uuid] += i.end - i.start + 1
+		}
+	}
+	return res
+}
+
+// total returns number of transactions in set.
+func (gs gtidSet) total() int64 {
+	var res int64
+	for _, n := range gs.counts() {
+		res += n
+	}
+	return res
+}
+
+// countMissing returns number of transactions in set which are not contained
+// in other set. Intervals of other set are expected to be disjoint, as they
+// are reported by server.
+func (gs gtidSet) countMissing(other gtidSet) int64 {
+	var res int64
+	for uuid, intervals := range gs {
+		for _, i := range intervals {
+			missing := i.end - i.start + 1
+			for _, o := range other[uuid] {
+				start, end := i.start, i.end
+				if o.start > start {
+					start = o.start
+				}
+				if o.end < end {
+					end = o.end
+				}
+				if end >= start {
+					missing -= end - start + 1
+				}
+			}
+			res += missing
+		}
+	}
+	return res
+}
@@ -0,0 +1,111 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	uuid1 = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	uuid2 = "8a94f357-aab4-11df-86ab-c80aa9429562"
+)
+
+func TestParseGtidSet(t *testing.T) {
+	Convey("parseGtidSet", t, func() {
+
+		Convey("counts transactions per source", func() {
+
+			dut, err := parseGtidSet(uuid1 + ":1-5:11-18,\n" + strings.ToUpper(uuid2) + ":7")
+
+			So(err, ShouldBeNil)
+			So(dut.counts()[uuid1], ShouldEqual, 13)
+			So(dut.counts()[uuid2], ShouldEqual, 1)
+			So(dut.total(), ShouldEqual, 14)
+
+		})
+
+		Convey("ignores tags", func() {
+
+			dut, err := parseGtidSet(uuid1 + ":1-5:mytag:1-3")
+
+			So(err, ShouldBeNil)
+			So(dut.counts()[uuid1], ShouldEqual, 8)
+
+		})
+
+		Convey("accepts empty set", func() {
+
+			dut, err := parseGtidSet("")
+
+			So(err, ShouldBeNil)
+			So(dut.total(), ShouldEqual, 0)
+
+		})
+
+		Convey("fails on malformed set", func() {
+
+			_, err := parseGtidSet(uuid1)
+			So(err, ShouldNotBeNil)
+
+			_, err = parseGtidSet(uuid1 + ":1-x")
+			So(err, ShouldNotBeNil)
+
+		})
+
+	})
+}
+
+func TestGtidSetCountMissing(t *testing.T) {
+	Convey("countMissing", t, func() {
+
+		retrieved, _ := parseGtidSet(uuid1 + ":1-100," + uuid2 + ":1-10")
+
+		Convey("counts transactions not contained in other set", func() {
+
+			executed, _ := parseGtidSet(uuid1 + ":1-90:95-97," + uuid2 + ":1-10")
+
+			So(retrieved.countMissing(executed), ShouldEqual, 7)
+
+		})
+
+		Convey("counts all transactions of unknown sources", func() {
+
+			executed, _ := parseGtidSet(uuid1 + ":1-100")
+
+			So(retrieved.countMissing(executed), ShouldEqual, 10)
+
+		})
+
+		Convey("ignores transactions of other set missing in this set", func() {
+
+			executed, _ := parseGtidSet(uuid1 + ":1-200," + uuid2 + ":1-10")
+
+			So(retrieved.countMissing(executed), ShouldEqual, 0)
+
+		})
+
+	})
+}
@@ -0,0 +1,252 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// timers are converted from picoseconds to nanoseconds
+const (
+	histogramGlobalQuery = `SELECT BUCKET_NUMBER, BUCKET_TIMER_LOW DIV 1000, BUCKET_TIMER_HIGH DIV 1000, COUNT_BUCKET
+	FROM performance_schema.events_statements_histogram_global
+	ORDER BY BUCKET_NUMBER`
+	histogramDigestQuery = `SELECT SCHEMA_NAME, DIGEST, BUCKET_NUMBER, BUCKET_TIMER_HIGH DIV 1000, COUNT_BUCKET
+	FROM performance_schema.events_statements_histogram_by_digest
+	WHERE COUNT_BUCKET > 0 AND DIGEST IS NOT NULL
+	ORDER BY SCHEMA_NAME, DIGEST, BUCKET_NUMBER`
+)
+
+// percentiles lists reported percentiles of statement latency.
+var percentiles = []int{50, 95, 99}
+
+// histogramBucket holds number of statements with latency in range of bucket.
+type histogramBucket struct {
+	number int64
+	// upper bound of bucket in nanoseconds
+	high  int64
+	count int64
+}
+
+// histogram holds buckets in ascending order of latency.
+type histogram []histogramBucket
+
+// delta returns histogram of statements counted since prev snapshot (bucket
+// number to count). Counts lower than previous ones (after reset of
+// statistics) are taken as they are.
+func (h histogram) delta(prev map[int64]int64) histogram {
+	res := make(histogram, len(h))
+	for i, b := range h {
+		res[i] = b
+		if p, ok := prev[b.number]; ok && p <= b.count {
+			res[i].count -= p
+		}
+	}
+	return res
+}
+
+// snapshot returns counts of buckets accessible by bucket number.
+func (h histogram) snapshot() map[int64]int64 {
+	res := map[int64]int64{}
+	for _, b := range h {
+		res[b.number] = b.count
+	}
+	return res
+}
+
+// total returns number of statements in histogram.
+func (h histogram) total() int64 {
+	var res int64
+	for _, b := range h {
+		res += b.count
+	}
+	return res
+}
+
+// percentile returns upper bound (in nanoseconds) of bucket holding given
+// percentile of statements, ok is false when histogram is empty.
+func (h histogram) percentile(p int) (int64, bool) {
+	total := h.total()
+	if total == 0 {
+		return 0, false
+	}
+
+	threshold := int64(math.Ceil(float64(total) * float64(p) / 100))
+
+	var seen int64
+	for _, b := range h {
+		seen += b.count
+		if seen >= threshold {
+			return b.high, true
+		}
+	}
+	return h[len(h)-1].high, true
+}
+
+// Histogram holds cumulative counts of statement latency histogram and
+// percentile of latency reported by Percentile stat.
+type Histogram struct {
+	buckets    histogram
+	percentile int
+}
+
+// NewHistogram returns histogram reporting given percentile, it's made of
+// buckets with given upper bounds (in nanoseconds) and cumulative counts.
+func NewHistogram(percentile int, bounds, counts []int64) *Histogram {
+	h := &Histogram{percentile: percentile}
+	for i := range bounds {
+		h.buckets = append(h.buckets, histogramBucket{number: int64(i), high: bounds[i], count: counts[i]})
+	}
+	return h
+}
+
+// PercentileSince returns percentile of latency (in microseconds) of
+// statements counted since prev histogram of the same stat. ok is false when
+// no statements were counted.
+func (h *Histogram) PercentileSince(prev *Histogram) (value int64, ok bool) {
+	value, ok = h.buckets.delta(prev.buckets.snapshot()).percentile(h.percentile)
+	return value / 1000, ok
+}
+
+// GetHistograms queries database for histograms of statement latency and
+// reports number of statements in each bucket and percentiles of latency
+// (in microseconds) of statements executed between two collections.
+// Percentiles of statement digests which executed the most statements are
+// reported as well when histograms of digests are enabled.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetHistograms() (Stats, error) {
+	rows, err := mysql.histogramGlobal.Query()
+	if err != nil {
+		return nil, fmt.Errorf("histogram request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+	global := histogram{}
+
+	for rows.Next() {
+		var b histogramBucket
+		var low int64
+
+		err = rows.Scan(&b.number, &low, &b.high, &b.count)
+		if err != nil {
+			return nil, fmt.Errorf("histogram request failed: %v", err)
+		}
+
+		global = append(global, b)
+
+		stat := counter(b.count)
+		stat.Tags = map[string]string{
+			"timer_low_ns":  strconv.FormatInt(low, 10),
+			"timer_high_ns": strconv.FormatInt(b.high, 10),
+		}
+		stats["statements/histogram/"+Dynamic("bucket", strconv.FormatInt(b.number, 10))+"/count"] = stat
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("histogram request failed: %v", err)
+	}
+
+	addPercentiles(stats, "statements/latency/p", global)
+
+	if mysql.histogramDigest != nil {
+		digests, err := mysql.queryDigestHistograms()
+		if err != nil {
+			return nil, fmt.Errorf("histogram request failed: %v", err)
+		}
+
+		prefixes := []string{}
+		for prefix := range digests {
+			prefixes = append(prefixes, prefix)
+		}
+
+		sort.Sort(byTotal{prefixes, digests})
+		if len(prefixes) > mysql.digestLimit {
+			prefixes = prefixes[:mysql.digestLimit]
+		}
+
+		for _, prefix := range prefixes {
+			addPercentiles(stats, prefix+"latency_p", digests[prefix])
+		}
+	}
+
+	return stats, nil
+}
+
+// queryDigestHistograms reads histograms of statement digests, they are
+// accessible by metric name prefix of digest.
+func (mysql *MySQLStats) queryDigestHistograms() (map[string]histogram, error) {
+	rows, err := mysql.histogramDigest.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := map[string]histogram{}
+
+	for rows.Next() {
+		var schema, digest interface{}
+		var b histogramBucket
+
+		err = rows.Scan(&schema, &digest, &b.number, &b.high, &b.count)
+		if err != nil {
+			return nil, err
+		}
+
+		schemaName := toString(schema)
+		if schemaName == "" {
+			schemaName = noSchemaName
+		}
+
+		prefix := "digest/" + Dynamic("schema", schemaName) + "/" + Dynamic("digest", toString(digest)) + "/"
+		res[prefix] = append(res[prefix], b)
+	}
+
+	return res, rows.Err()
+}
+
+// addPercentiles adds percentiles of statements counted in h to stats, names
+// of stats are made of prefix followed by percentile.
+func addPercentiles(stats Stats, prefix string, h histogram) {
+	for _, p := range percentiles {
+		stats[prefix+strconv.Itoa(p)] = Stat{Type: Percentile, Histogram: &Histogram{buckets: h, percentile: p}}
+	}
+}
+
+// byTotal sorts prefixes of digests by number of statements in their
+// histograms (since startup), from the highest one.
+type byTotal struct {
+	prefixes   []string
+	histograms map[string]histogram
+}
+
+func (b byTotal) Len() int      { return len(b.prefixes) }
+func (b byTotal) Swap(i, j int) { b.prefixes[i], b.prefixes[j] = b.prefixes[j], b.prefixes[i] }
+func (b byTotal) Less(i, j int) bool {
+	ti, tj := b.histograms[b.prefixes[i]].total(), b.histograms[b.prefixes[j]].total()
+	if ti != tj {
+		return ti > tj
+	}
+	return b.prefixes[i] < b.prefixes[j]
+}
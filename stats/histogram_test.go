@@ -0,0 +1,152 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	Convey("histogram.percentile", t, func() {
+
+		sut := histogram{
+			{number: 0, high: 1000, count: 50},
+			{number: 1, high: 10000, count: 50},
+			{number: 2, high: 100000, count: 40},
+			{number: 3, high: 1000000, count: 10},
+		}
+
+		Convey("returns upper bound of bucket holding percentile", func() {
+			dut, ok := sut.percentile(50)
+			So(ok, ShouldBeTrue)
+			So(dut, ShouldEqual, 10000)
+
+			dut, _ = sut.percentile(95)
+			So(dut, ShouldEqual, 1000000)
+		})
+
+		Convey("fails for empty histogram", func() {
+			_, ok := histogram{{number: 0, high: 1000, count: 0}}.percentile(50)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("delta subtracts previous counts", func() {
+			dut := sut.delta(map[int64]int64{0: 20, 1: 50, 3: 20})
+			So(dut[0].count, ShouldEqual, 30)
+			So(dut[1].count, ShouldEqual, 0)
+			So(dut[2].count, ShouldEqual, 40)
+			// counts were reset
+			So(dut[3].count, ShouldEqual, 10)
+		})
+
+	})
+}
+
+func TestGetHistograms(t *testing.T) {
+	Convey("GetHistograms", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		columns := []string{"BUCKET_NUMBER", "BUCKET_TIMER_LOW", "BUCKET_TIMER_HIGH", "COUNT_BUCKET"}
+		digestColumns := []string{"SCHEMA_NAME", "DIGEST", "BUCKET_NUMBER", "BUCKET_TIMER_HIGH", "COUNT_BUCKET"}
+
+		globalStmt := mock.ExpectPrepare("events_statements_histogram_global")
+		digestStmt := mock.ExpectPrepare("events_statements_histogram_by_digest")
+
+		globalStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(0, 0, 1000, 100).
+			AddRow(1, 1000, 10000, 50).
+			AddRow(2, 10000, 100000, 10).
+			AddRow(3, 100000, 1000000, 0))
+		digestStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(digestColumns).
+			AddRow("shop", "abc", 0, 1000, 90).
+			AddRow("shop", "def", 2, 100000, 10))
+		globalStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(0, 0, 1000, 150).
+			AddRow(1, 1000, 10000, 100).
+			AddRow(2, 10000, 100000, 50).
+			AddRow(3, 100000, 1000000, 10))
+		digestStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(digestColumns).
+			AddRow("shop", "abc", 0, 1000, 91).
+			AddRow("shop", "def", 2, 100000, 40).
+			AddRow("shop", "def", 3, 1000000, 60))
+
+		sut := &MySQLStats{
+			db:              db,
+			histogramGlobal: prepareOptional(db, histogramGlobalQuery),
+			histogramDigest: prepareOptional(db, histogramDigestQuery),
+			digestLimit:     1,
+		}
+
+		dut1, err1 := sut.GetHistograms()
+
+		Convey("reports bucket counts with their bounds", func() {
+
+			So(err1, ShouldBeNil)
+			So(dut1["statements/histogram/[bucket=1]/count"].Value, ShouldEqual, 50)
+			So(dut1["statements/histogram/[bucket=1]/count"].Type, ShouldEqual, Counter)
+			So(dut1["statements/histogram/[bucket=1]/count"].Tags["timer_low_ns"], ShouldEqual, "1000")
+			So(dut1["statements/histogram/[bucket=1]/count"].Tags["timer_high_ns"], ShouldEqual, "10000")
+
+		})
+
+		Convey("reports percentiles to be calculated by requester", func() {
+
+			So(dut1["statements/latency/p50"].Type, ShouldEqual, Percentile)
+			So(dut1["statements/latency/p50"].Histogram, ShouldNotBeNil)
+
+		})
+
+		Convey("reports percentiles of statements between two collections", func() {
+
+			dut2, err2 := sut.GetHistograms()
+
+			So(err2, ShouldBeNil)
+
+			percentile := func(name string) int64 {
+				value, ok := dut2[name].Histogram.PercentileSince(dut1[name].Histogram)
+				So(ok, ShouldBeTrue)
+				return value
+			}
+
+			So(percentile("statements/latency/p50"), ShouldEqual, 10)
+			So(percentile("statements/latency/p95"), ShouldEqual, 1000)
+			So(percentile("statements/latency/p99"), ShouldEqual, 1000)
+
+			Convey("of digests which executed the most statements since startup", func() {
+
+				So(dut1, ShouldContainKey, "digest/[schema=shop]/[digest=abc]/latency_p50")
+				So(dut1, ShouldNotContainKey, "digest/[schema=shop]/[digest=def]/latency_p50")
+
+				// def overtook abc
+				So(dut2, ShouldContainKey, "digest/[schema=shop]/[digest=def]/latency_p50")
+				So(dut2, ShouldNotContainKey, "digest/[schema=shop]/[digest=abc]/latency_p50")
+
+			})
+
+		})
+
+	})
+}
@@ -0,0 +1,93 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// indexUsageQuery selects I/O wait counts of indexes, rows without index name
+// represent reads done without any index and are skipped.
+const indexUsageQuery = `SELECT OBJECT_SCHEMA, OBJECT_NAME, INDEX_NAME, COUNT_READ, COUNT_WRITE
+	FROM performance_schema.table_io_waits_summary_by_index_usage
+	WHERE OBJECT_TYPE = 'TABLE' AND INDEX_NAME IS NOT NULL`
+
+// primaryIndexName is name of primary key index, which is never reported
+// as unused.
+const primaryIndexName = "PRIMARY"
+
+// GetIndexUsage queries database for I/O wait counts of indexes of tables
+// selected by table filter. For each schema number and list of indexes which
+// were not used to read rows since server startup is reported as well.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetIndexUsage() (Stats, error) {
+	rows, err := mysql.indexUsage.Query()
+	if err != nil {
+		return nil, fmt.Errorf("index usage request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+
+	// unused indexes (as table.index) accessible by schema name
+	unused := map[string][]string{}
+
+	for rows.Next() {
+		var schema, table, index string
+		var reads, writes interface{}
+
+		err = rows.Scan(&schema, &table, &index, &reads, &writes)
+		if err != nil {
+			return nil, fmt.Errorf("index usage request failed: %v", err)
+		}
+
+		if !mysql.tables.match(schema, table) {
+			continue
+		}
+
+		prefix := "index_usage/" + Dynamic("schema", schema) + "/" + Dynamic("table", table) + "/" + Dynamic("index", index) + "/"
+		stats[prefix+"read"] = counter(reads)
+		stats[prefix+"write"] = counter(writes)
+
+		if _, ok := unused[schema]; !ok {
+			unused[schema] = []string{}
+		}
+		if toInt(reads) == 0 && index != primaryIndexName {
+			unused[schema] = append(unused[schema], table+"."+index)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("index usage request failed: %v", err)
+	}
+
+	for schema, indexes := range unused {
+		sort.Strings(indexes)
+
+		prefix := "index_usage/" + Dynamic("schema", schema) + "/"
+		stats[prefix+"unused"] = gauge(len(indexes))
+		stats[prefix+"unused_list"] = text(strings.Join(indexes, ","))
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,67 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetIndexUsage(t *testing.T) {
+	Convey("GetIndexUsage", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectPrepare("table_io_waits_summary_by_index_usage").ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"OBJECT_SCHEMA", "OBJECT_NAME", "INDEX_NAME", "COUNT_READ", "COUNT_WRITE"}).
+				AddRow("shop", "orders", "PRIMARY", 0, 10).
+				AddRow("shop", "orders", "idx_customer", 500, 10).
+				AddRow("shop", "orders", "idx_status", 0, 10).
+				AddRow("shop", "customers", "idx_email", 0, 2).
+				AddRow("crm", "leads", "PRIMARY", 40, 1))
+
+		sut := &MySQLStats{db: db, indexUsage: prepareOptional(db, indexUsageQuery)}
+
+		dut, err := sut.GetIndexUsage()
+
+		Convey("reports reads and writes of each index", func() {
+
+			So(err, ShouldBeNil)
+			So(dut["index_usage/[schema=shop]/[table=orders]/[index=idx_customer]/read"].Value, ShouldEqual, 500)
+			So(dut["index_usage/[schema=shop]/[table=orders]/[index=idx_customer]/read"].Type, ShouldEqual, Counter)
+			So(dut["index_usage/[schema=shop]/[table=orders]/[index=idx_customer]/write"].Value, ShouldEqual, 10)
+
+		})
+
+		Convey("reports unused indexes of each schema", func() {
+
+			So(dut["index_usage/[schema=shop]/unused"].Value, ShouldEqual, 2)
+			So(dut["index_usage/[schema=shop]/unused_list"].Text, ShouldEqual, "customers.idx_email,orders.idx_status")
+			So(dut["index_usage/[schema=crm]/unused"].Value, ShouldEqual, 0)
+			So(dut["index_usage/[schema=crm]/unused_list"].Text, ShouldEqual, "")
+
+		})
+
+	})
+}
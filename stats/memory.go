@@ -0,0 +1,114 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const memoryQuery = "SELECT EVENT_NAME, CURRENT_NUMBER_OF_BYTES_USED FROM performance_schema.memory_summary_global_by_event_name"
+
+// DefaultMemoryLimit is number of memory instruments reported when
+// Options.MemoryLimit is not set.
+const DefaultMemoryLimit = 20
+
+// memoryAreas lists memory areas whose totals are reported.
+var memoryAreas = []string{"innodb", "sql", "temptable", "performance_schema"}
+
+// memoryUsage holds current memory usage of single instrument.
+type memoryUsage struct {
+	event string
+	bytes int64
+}
+
+// GetMemory queries database for memory currently allocated by server.
+// Instruments with the highest allocation are reported along with totals of
+// memory areas.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetMemory() (Stats, error) {
+	if !mysql.supportsMemory {
+		return nil, fmt.Errorf("memory stats not supported on current version of mysql server")
+	}
+
+	rows, err := mysql.memory.Query()
+	if err != nil {
+		return nil, fmt.Errorf("memory request failed: %v", err)
+	}
+	defer rows.Close()
+
+	usages := byBytes{}
+	areas := map[string]int64{}
+	var total int64
+
+	for rows.Next() {
+		var name string
+		var bytes int64
+
+		err = rows.Scan(&name, &bytes)
+		if err != nil {
+			return nil, fmt.Errorf("memory request failed: %v", err)
+		}
+
+		event := strings.TrimPrefix(name, "memory/")
+		area := strings.SplitN(event, "/", 2)[0]
+
+		areas[area] += bytes
+		total += bytes
+		usages = append(usages, memoryUsage{event: event, bytes: bytes})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("memory request failed: %v", err)
+	}
+
+	stats := Stats{}
+
+	stats["memory/total/current_bytes"] = gauge(total)
+	for _, area := range memoryAreas {
+		stats["memory/area/"+area+"/current_bytes"] = gauge(areas[area])
+	}
+
+	sort.Sort(usages)
+
+	for i, usage := range usages {
+		if i >= mysql.memoryLimit {
+			break
+		}
+		stats["memory/event/"+Dynamic("event", usage.event)+"/current_bytes"] = gauge(usage.bytes)
+	}
+
+	return stats, nil
+}
+
+// byBytes sorts memory usages from the highest one, usages of equal size are
+// sorted by instrument name.
+type byBytes []memoryUsage
+
+func (u byBytes) Len() int      { return len(u) }
+func (u byBytes) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u byBytes) Less(i, j int) bool {
+	if u[i].bytes != u[j].bytes {
+		return u[i].bytes > u[j].bytes
+	}
+	return u[i].event < u[j].event
+}
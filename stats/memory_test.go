@@ -0,0 +1,81 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetMemory(t *testing.T) {
+	Convey("GetMemory", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectPrepare("memory_summary_global_by_event_name").ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"EVENT_NAME", "CURRENT_NUMBER_OF_BYTES_USED"}).
+				AddRow("memory/innodb/buf_buf_pool", 137428992).
+				AddRow("memory/innodb/hash0hash", 2000000).
+				AddRow("memory/sql/TABLE", 5000000).
+				AddRow("memory/temptable/physical_ram", 1048576).
+				AddRow("memory/performance_schema/table_handles", 9000000).
+				AddRow("memory/mysys/KEY_CACHE", 8192))
+
+		sut := &MySQLStats{db: db, supportsMemory: true, memory: prepareOptional(db, memoryQuery), memoryLimit: 2}
+
+		Convey("reports totals of memory areas", func() {
+
+			dut, err := sut.GetMemory()
+
+			So(err, ShouldBeNil)
+			So(dut["memory/total/current_bytes"].Value, ShouldEqual, 154485760)
+			So(dut["memory/area/innodb/current_bytes"].Value, ShouldEqual, 139428992)
+			So(dut["memory/area/sql/current_bytes"].Value, ShouldEqual, 5000000)
+			So(dut["memory/area/temptable/current_bytes"].Value, ShouldEqual, 1048576)
+			So(dut["memory/area/performance_schema/current_bytes"].Value, ShouldEqual, 9000000)
+
+		})
+
+		Convey("reports instruments with the highest allocation", func() {
+
+			dut, _ := sut.GetMemory()
+
+			So(dut["memory/event/[event=innodb_buf_buf_pool]/current_bytes"].Value, ShouldEqual, 137428992)
+			So(dut["memory/event/[event=performance_schema_table_handles]/current_bytes"].Value, ShouldEqual, 9000000)
+			So(dut, ShouldNotContainKey, "memory/event/[event=sql_TABLE]/current_bytes")
+
+		})
+
+		Convey("fails on server without memory instrumentation", func() {
+
+			sut.supportsMemory = false
+
+			_, err := sut.GetMemory()
+
+			So(err, ShouldNotBeNil)
+
+		})
+
+	})
+}
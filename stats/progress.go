@@ -0,0 +1,152 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// progressQuery selects current stages which estimate their work along with
+// statements executing them, timers are converted from picoseconds to
+// microseconds.
+const progressQuery = `SELECT stg.THREAD_ID, thr.PROCESSLIST_ID, stg.EVENT_NAME,
+	stg.WORK_COMPLETED, stg.WORK_ESTIMATED, stmt.TIMER_WAIT DIV 1000000, stmt.CURRENT_SCHEMA, stmt.SQL_TEXT
+	FROM performance_schema.events_stages_current stg
+	JOIN performance_schema.threads thr ON thr.THREAD_ID = stg.THREAD_ID
+	LEFT JOIN performance_schema.events_statements_current stmt
+	ON stmt.THREAD_ID = stg.THREAD_ID AND stmt.EVENT_ID = stg.NESTING_EVENT_ID
+	WHERE stg.WORK_ESTIMATED IS NOT NULL`
+
+// noTableName is used as table element of operations whose table couldn't be
+// determined from statement text.
+const noTableName = "none"
+
+// stageTag is name of tag holding name of current stage of operation.
+const stageTag = "stage"
+
+// progressNames lists last elements of names of stats reported for each
+// operation.
+var progressNames = []string{"work_completed", "work_estimated", "progress_ppm", "elapsed", "connection_id"}
+
+// identifier matches optionally quoted schema or table name.
+const identifier = "(`[^`]+`|[^\\s.(;`]+)"
+
+// progressTable extracts (optionally qualified) table name of ALTER TABLE,
+// OPTIMIZE TABLE, CREATE/DROP INDEX and LOAD DATA statements.
+var progressTable = regexp.MustCompile(`(?is)^\s*(?:ALTER\s+(?:ONLINE\s+)?(?:IGNORE\s+)?TABLE` +
+	`|OPTIMIZE\s+(?:NO_WRITE_TO_BINLOG\s+|LOCAL\s+)?TABLE` +
+	`|(?:CREATE\s+(?:UNIQUE\s+|FULLTEXT\s+|SPATIAL\s+)?|DROP\s+)INDEX\s+\S+\s+ON` +
+	`|LOAD\s+DATA\s.*?\sINTO\s+TABLE)\s+` + identifier + `(?:\.` + identifier + `)?`)
+
+// statementTable returns schema and table modified by statement, schema
+// defaults to current schema of statement.
+func statementTable(sqlText, currentSchema string) (schema, table string) {
+	schema, table = currentSchema, noTableName
+	if m := progressTable.FindStringSubmatch(sqlText); m != nil {
+		if m[2] != "" {
+			schema, table = m[1], m[2]
+		} else {
+			table = m[1]
+		}
+	}
+	if schema == "" {
+		schema = noSchemaName
+	}
+	return strings.Trim(schema, "`"), strings.Trim(table, "`")
+}
+
+// progressPrefix returns common part of names of stats of operation run by
+// given thread on given table.
+func progressPrefix(thread, schema, table string) string {
+	return "progress/" + Dynamic("thread", thread) + "/" + Dynamic("schema", schema) + "/" +
+		Dynamic("table", table) + "/"
+}
+
+// ProgressTemplates returns names of progress stats with empty dynamic
+// elements. Operations are rarely running during metric discovery, so names
+// can't be taken from stats returned by GetProgress.
+func ProgressTemplates() []string {
+	res := make([]string, 0, len(progressNames))
+	for _, name := range progressNames {
+		res = append(res, progressPrefix("", "", "")+name)
+	}
+	return res
+}
+
+// GetProgress queries database for progress of long-running operations, ex.
+// ALTER TABLE, which estimate amount of their work. Operations are reported
+// by performance schema thread id and by schema and table parsed from
+// statement text, so concurrent operations on the same table don't overwrite
+// each other. Current stage is attached as a tag.
+// Stats are only returned when consumer events_stages_current (along with
+// events_statements_current) and instruments of reported stages, ex.
+// stage/innodb/alter%, are enabled, they are disabled by default.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetProgress() (Stats, error) {
+	rows, err := mysql.progress.Query()
+	if err != nil {
+		return nil, fmt.Errorf("progress request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+
+	for rows.Next() {
+		var threadID int64
+		var stage string
+		var connectionID, completed, estimated, elapsed, currentSchema, sqlText interface{}
+
+		err = rows.Scan(&threadID, &connectionID, &stage, &completed, &estimated, &elapsed, &currentSchema, &sqlText)
+		if err != nil {
+			return nil, fmt.Errorf("progress request failed: %v", err)
+		}
+
+		schema, table := statementTable(toString(sqlText), toString(currentSchema))
+
+		prefix := progressPrefix(strconv.FormatInt(threadID, 10), schema, table)
+		tags := map[string]string{stageTag: stage}
+
+		progress := Stat{Type: Gauge, IsNull: true}
+		if total := toInt(estimated); total > 0 {
+			progress = gauge(toInt(completed) * 1000000 / total)
+		}
+
+		for k, v := range map[string]Stat{
+			"work_completed": gauge(completed),
+			"work_estimated": gauge(estimated),
+			"progress_ppm":   progress,
+			"elapsed":        gauge(elapsed),
+			"connection_id":  gauge(connectionID),
+		} {
+			v.Tags = tags
+			stats[prefix+k] = v
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("progress request failed: %v", err)
+	}
+
+	return stats, nil
+}
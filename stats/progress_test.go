@@ -0,0 +1,143 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStatementTable(t *testing.T) {
+	Convey("statementTable", t, func() {
+
+		Convey("extracts qualified table name", func() {
+			schema, table := statementTable("ALTER TABLE `shop`.`orders` ADD INDEX (created)", "test")
+			So(schema, ShouldEqual, "shop")
+			So(table, ShouldEqual, "orders")
+		})
+
+		Convey("uses current schema for unqualified table name", func() {
+			schema, table := statementTable("CREATE INDEX ix_created ON orders (created)", "test")
+			So(schema, ShouldEqual, "test")
+			So(table, ShouldEqual, "orders")
+		})
+
+		Convey("extracts table of LOAD DATA statement", func() {
+			schema, table := statementTable("LOAD DATA INFILE '/tmp/orders.csv' INTO TABLE shop.orders", "")
+			So(schema, ShouldEqual, "shop")
+			So(table, ShouldEqual, "orders")
+		})
+
+		Convey("falls back to placeholders for unknown statements", func() {
+			schema, table := statementTable("", "")
+			So(schema, ShouldEqual, noSchemaName)
+			So(table, ShouldEqual, noTableName)
+		})
+
+	})
+}
+
+func TestGetProgress(t *testing.T) {
+	Convey("GetProgress", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectPrepare("events_stages_current").ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"THREAD_ID", "PROCESSLIST_ID", "EVENT_NAME", "WORK_COMPLETED", "WORK_ESTIMATED",
+				"TIMER_WAIT", "CURRENT_SCHEMA", "SQL_TEXT"}).
+				AddRow(48, 12, "stage/innodb/alter table (read PK and internal sort)", 250, 1000,
+					95000000, "shop", "ALTER TABLE orders ADD COLUMN note TEXT").
+				AddRow(51, 15, "stage/sql/copy to tmp table", 0, 0, nil, nil, nil))
+
+		sut := &MySQLStats{db: db, progress: prepareOptional(db, progressQuery)}
+
+		Convey("reports progress of operations by table", func() {
+
+			dut, err := sut.GetProgress()
+
+			So(err, ShouldBeNil)
+			So(dut["progress/[thread=48]/[schema=shop]/[table=orders]/work_completed"].Value, ShouldEqual, 250)
+			So(dut["progress/[thread=48]/[schema=shop]/[table=orders]/work_estimated"].Value, ShouldEqual, 1000)
+			So(dut["progress/[thread=48]/[schema=shop]/[table=orders]/progress_ppm"].Value, ShouldEqual, 250000)
+			So(dut["progress/[thread=48]/[schema=shop]/[table=orders]/elapsed"].Value, ShouldEqual, 95000000)
+			So(dut["progress/[thread=48]/[schema=shop]/[table=orders]/connection_id"].Value, ShouldEqual, 12)
+
+		})
+
+		Convey("reports concurrent operations on the same table separately", func() {
+
+			db, mock, _ := sqlmock.New()
+			mock.ExpectPrepare("events_stages_current").ExpectQuery().WillReturnRows(
+				sqlmock.NewRows([]string{"THREAD_ID", "PROCESSLIST_ID", "EVENT_NAME", "WORK_COMPLETED", "WORK_ESTIMATED",
+					"TIMER_WAIT", "CURRENT_SCHEMA", "SQL_TEXT"}).
+					AddRow(48, 12, "stage/innodb/alter table (read PK and internal sort)", 250, 1000,
+						95000000, "shop", "ALTER TABLE orders ADD COLUMN note TEXT").
+					AddRow(60, 20, "stage/sql/optimizing", 10, 100,
+						1000, "shop", "OPTIMIZE TABLE orders"))
+			sut := &MySQLStats{db: db, progress: prepareOptional(db, progressQuery)}
+
+			dut, err := sut.GetProgress()
+
+			So(err, ShouldBeNil)
+			So(dut["progress/[thread=48]/[schema=shop]/[table=orders]/work_completed"].Value, ShouldEqual, 250)
+			So(dut["progress/[thread=60]/[schema=shop]/[table=orders]/work_completed"].Value, ShouldEqual, 10)
+			So(dut["progress/[thread=60]/[schema=shop]/[table=orders]/connection_id"].Value, ShouldEqual, 20)
+
+		})
+
+		Convey("reports stats listed by templates", func() {
+
+			dut, _ := sut.GetProgress()
+
+			for _, name := range ProgressTemplates() {
+				So(name, ShouldStartWith, "progress/[thread=]/[schema=]/[table=]/")
+				suffix := strings.TrimPrefix(name, "progress/[thread=]/[schema=]/[table=]/")
+				So(dut, ShouldContainKey, "progress/[thread=48]/[schema=shop]/[table=orders]/"+suffix)
+			}
+			So(len(dut), ShouldEqual, 2*len(ProgressTemplates()))
+
+		})
+
+		Convey("attaches current stage as a tag", func() {
+
+			dut, _ := sut.GetProgress()
+
+			So(dut["progress/[thread=48]/[schema=shop]/[table=orders]/work_completed"].Tags[stageTag], ShouldEqual,
+				"stage/innodb/alter table (read PK and internal sort)")
+
+		})
+
+		Convey("reports null progress when work is not estimated yet", func() {
+
+			dut, _ := sut.GetProgress()
+
+			So(dut["progress/[thread=51]/[schema=none]/[table=none]/progress_ppm"].IsNull, ShouldBeTrue)
+			So(dut["progress/[thread=51]/[schema=none]/[table=none]/elapsed"].IsNull, ShouldBeTrue)
+			So(dut["progress/[thread=51]/[schema=none]/[table=none]/work_estimated"].Value, ShouldEqual, 0)
+
+		})
+
+	})
+}
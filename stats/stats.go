@@ -23,8 +23,11 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	// don't remove this line, driver registration is done in module's init
 	_ "github.com/go-sql-driver/mysql"
@@ -37,48 +40,235 @@ const (
 	Derive
 	// Counter metric type
 	Counter
+	// Text metric type, value is held in Stat.Text
+	Text
+	// Percentile metric type, value is percentile of Stat.Histogram over
+	// interval between two collections, it's calculated by requester
+	Percentile
 )
 
-const (
-	slaveReadMasterIDX       = 6
-	slaveIoRunningIDX        = 10
-	slaveSQLRunningIDX       = 11
-	slaveExecMasterLogPosIDX = 21
-	slaveSecondsBehindIDX    = 32
-)
+// tableNameRegexp matches table name optionally qualified with database name.
+var tableNameRegexp = regexp.MustCompile(`^[0-9A-Za-z_$]+(\.[0-9A-Za-z_$]+)?$`)
+
+// slaveColumnAliases maps column names of replica status used by newer servers
+// (SHOW REPLICA STATUS on MySQL 8.0.22+) to their classic SHOW SLAVE STATUS
+// counterparts, which are used to look up values.
+var slaveColumnAliases = map[string]string{
+	"Source_Host":                   "Master_Host",
+	"Source_User":                   "Master_User",
+	"Source_Port":                   "Master_Port",
+	"Source_Log_File":               "Master_Log_File",
+	"Read_Source_Log_Pos":           "Read_Master_Log_Pos",
+	"Relay_Source_Log_File":         "Relay_Master_Log_File",
+	"Replica_IO_State":              "Slave_IO_State",
+	"Replica_IO_Running":            "Slave_IO_Running",
+	"Replica_SQL_Running":           "Slave_SQL_Running",
+	"Exec_Source_Log_Pos":           "Exec_Master_Log_Pos",
+	"Seconds_Behind_Source":         "Seconds_Behind_Master",
+	"Source_SSL_Allowed":            "Master_SSL_Allowed",
+	"Source_Server_Id":              "Master_Server_Id",
+	"Source_UUID":                   "Master_UUID",
+	"Source_Info_File":              "Master_Info_File",
+	"Replica_SQL_Running_State":     "Slave_SQL_Running_State",
+	"Source_Retry_Count":            "Master_Retry_Count",
+	"Source_Bind":                   "Master_Bind",
+	"Source_TLS_Version":            "Master_TLS_Version",
+	"Source_public_key_path":        "Master_public_key_path",
+	"Get_Source_public_key":         "Get_master_public_key",
+	"Source_SSL_Verify_Server_Cert": "Master_SSL_Verify_Server_Cert",
+	// MariaDB's SHOW ALL SLAVES STATUS
+	"Connection_name": "Channel_Name",
+}
+
+// defaultChannelName is used as channel element of replication metrics of
+// the default (unnamed) replication channel.
+const defaultChannelName = "default"
 
 // Stat describes single statistics.
 // Value holds stat value.
-// Type is either TYPE_GAUGE, TYPE_DERIVE or TYPE_COUNTER.
+// Text holds stat value for Text type.
+// Type is either Gauge, Derive, Counter, Text or Percentile.
 // IsNull indicates if value is null.
+// Tags holds additional information describing stat, may be nil.
+// Histogram holds cumulative histogram for Percentile type.
 type Stat struct {
-	Value  int64
-	Type   int
-	IsNull bool
+	Value     int64
+	Text      string
+	Type      int
+	IsNull    bool
+	Tags      map[string]string
+	Histogram *Histogram
 }
 
 // Stats is collection of statistics accessible by name (which may include '/').
 type Stats map[string]Stat
 
+// Options holds settings of MySQLStats. Zero value of each connection pool
+// field leaves corresponding database/sql default untouched.
+type Options struct {
+	// MaxOpenConns limits number of open connections to database.
+	MaxOpenConns int
+	// MaxIdleConns limits number of connections kept in idle pool.
+	MaxIdleConns int
+	// ConnMaxLifetime is maximum amount of time connection may be reused.
+	ConnMaxLifetime time.Duration
+
+	// HeartbeatTable is name of pt-heartbeat table (optionally qualified with
+	// database name), heartbeat lag is not measured when it's empty.
+	HeartbeatTable string
+	// HeartbeatUTC indicates that heartbeat timestamps are written in UTC
+	// (pt-heartbeat --utc), otherwise they are in server's time zone.
+	HeartbeatUTC bool
+	// HeartbeatServerID is server_id of primary whose heartbeat is used to
+	// measure lag, when 0 the most recent heartbeat is used.
+	HeartbeatServerID int64
+
+	// DigestLimit is number of statement digests with the highest total
+	// latency which are reported, DefaultDigestLimit is used when it's 0.
+	DigestLimit int
+
+	// SchemaInclude, SchemaExclude, TableInclude and TableExclude are regular
+	// expressions selecting tables reported by per-table calls, empty ones
+	// don't restrict selection.
+	SchemaInclude, SchemaExclude string
+	TableInclude, TableExclude   string
+
+	// FileInclude and FileExclude are regular expressions selecting files
+	// whose I/O statistics are reported individually, no files are reported
+	// when FileInclude is empty.
+	FileInclude, FileExclude string
+
+	// MemoryLimit is number of memory instruments with the highest current
+	// allocation which are reported, DefaultMemoryLimit is used when it's 0.
+	MemoryLimit int
+
+	// HistogramDigests enables percentiles of statement latency of digests,
+	// DigestLimit digests which executed the most statements are reported.
+	HistogramDigests bool
+
+	// ContentionLimit is number of mutex and rw-lock instruments with the
+	// highest total wait time which are reported, DefaultContentionLimit is
+	// used when it's 0.
+	ContentionLimit int
+
+	// ErrorLimit is number of errors raised the most times which are
+	// reported, DefaultErrorLimit is used when it's 0.
+	ErrorLimit int
+
+	// TransactionAccounts enables transaction statistics of each account.
+	TransactionAccounts bool
+}
+
 // MySQLStats implements statistics gathering from MySQL database.
 type MySQLStats struct {
 	db             *sql.DB
 	version        uint
+	mariadb        bool
 	supportsInnodb bool
+	supportsMemory bool
 
 	stats, innodb, master, slave *sql.Stmt
+
+	binlogs, heartbeat *optionalStmt
+
+	groupMembers, groupStats, groupFlowControl *optionalStmt
+
+	galeraEnabled, galeraStatus *optionalStmt
+
+	applierWorkers, replicaConnection *optionalStmt
+
+	digests     *optionalStmt
+	digestLimit int
+
+	tableIO, indexUsage *optionalStmt
+
+	// selects tables reported by per-table calls
+	tables *tableFilter
+
+	fileEvents, fileUndo, fileInstances *optionalStmt
+
+	// I/O statistics of undo tablespaces summed across their truncations
+	undoIO fileCounters
+
+	// selects files reported individually, nil if none
+	files *fileFilter
+
+	waits *optionalStmt
+
+	contention      *optionalStmt
+	contentionLimit int
+
+	errors     *optionalStmt
+	errorLimit int
+
+	progress *optionalStmt
+
+	transactions, accountTransactions       *optionalStmt
+	transactionConsumer, transactionHistory *optionalStmt
+
+	// committed and rolled back transactions counted from history
+	transactionEnds transactionEnds
+
+	memory      *optionalStmt
+	memoryLimit int
+
+	users, hosts, accounts, accountStatements *optionalStmt
+
+	histogramGlobal, histogramDigest *optionalStmt
+
+	heartbeatServerID int64
+
+	// position of master in binary log
+	masterPos binlogPosition
+}
+
+// optionalStmt is statement of call which may be unsupported by server
+// (because of its version, configuration or privileges). Error of preparation
+// is not fatal, it is returned when statement is queried.
+type optionalStmt struct {
+	stmt *sql.Stmt
+	err  error
+}
+
+// prepareOptional prepares statement of optional call.
+func prepareOptional(db *sql.DB, query string) *optionalStmt {
+	stmt, err := db.Prepare(query)
+	return &optionalStmt{stmt: stmt, err: err}
+}
+
+// Query executes statement. Error is returned if statement was not prepared,
+// nil statement means it is not supported by server version.
+func (o *optionalStmt) Query(args ...interface{}) (*sql.Rows, error) {
+	if o == nil {
+		return nil, fmt.Errorf("not supported on current version of mysql server")
+	}
+	if o.err != nil {
+		return nil, o.err
+	}
+	return o.stmt.Query(args...)
 }
 
 // New constructs MySQLStats object, returns error when fails.
 // connectionString is passed to sql.Open(), please refer to sql module
-// documentation to learn about syntax.
-func New(connectionString string) (*MySQLStats, error) {
+// documentation to learn about syntax. opts configures connection pool which
+// is shared by all queries, so they can be performed concurrently.
+func New(connectionString string, opts Options) (*MySQLStats, error) {
 	var err error
 	db, err := sqlOpen("mysql", connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("sql open failed: %v", err)
 	}
 
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+
 	err = db.Ping()
 
 	if err != nil {
@@ -96,7 +286,7 @@ func New(connectionString string) (*MySQLStats, error) {
 
 	ver := parseVersion(verStr)
 
-	res := &MySQLStats{db: db, version: ver}
+	res := &MySQLStats{db: db, version: ver, mariadb: isMariaDB(verStr)}
 
 	if ver >= 50002 {
 		res.stats, err = db.Prepare("SHOW GLOBAL STATUS")
@@ -122,10 +312,127 @@ func New(connectionString string) (*MySQLStats, error) {
 		return nil, fmt.Errorf("cannot prepare master status statement: %v", err)
 	}
 
-	res.slave, err = db.Prepare("SHOW SLAVE STATUS")
+	res.slave, err = db.Prepare(res.slaveStatusQuery())
 	if err != nil {
 		return nil, fmt.Errorf("cannot prepare slave status statement: %v", err)
 	}
+
+	res.binlogs = prepareOptional(db, "SHOW BINARY LOGS")
+
+	// performance_schema tables of Group Replication are available since
+	// MySQL 5.7.17
+	if !res.mariadb && ver >= 50717 {
+		res.groupMembers = prepareOptional(db, groupMembersQuery)
+		res.groupStats = prepareOptional(db, groupMemberStatsQuery)
+		res.groupFlowControl = prepareOptional(db, groupFlowControlQuery)
+	}
+
+	// performance_schema replication tables are available since MySQL 5.7.2
+	if !res.mariadb && ver >= 50702 {
+		res.applierWorkers = prepareOptional(db, applierWorkersQuery)
+		res.replicaConnection = prepareOptional(db, replicaConnectionQuery)
+	}
+
+	res.tables, err = newTableFilter(opts.SchemaInclude, opts.SchemaExclude, opts.TableInclude, opts.TableExclude)
+	if err != nil {
+		return nil, err
+	}
+
+	res.files, err = newFileFilter(opts.FileInclude, opts.FileExclude)
+	if err != nil {
+		return nil, err
+	}
+
+	// table, index and file I/O waits (with timers) and account summaries
+	// are available since MySQL 5.6.3
+	if ver >= 50603 {
+		res.tableIO = prepareOptional(db, tableIOQuery)
+		res.indexUsage = prepareOptional(db, indexUsageQuery)
+		res.users = prepareOptional(db, usersQuery)
+		res.hosts = prepareOptional(db, hostsQuery)
+		res.accounts = prepareOptional(db, accountsQuery)
+		res.accountStatements = prepareOptional(db, accountStatementsQuery)
+		res.fileEvents = prepareOptional(db, fileEventsQuery)
+		res.fileUndo = prepareOptional(db, fileUndoQuery)
+		if res.files != nil {
+			res.fileInstances = prepareOptional(db, fileInstancesQuery)
+		}
+	}
+
+	// statement histograms are available since MySQL 8.0
+	if !res.mariadb && ver >= 80000 {
+		res.histogramGlobal = prepareOptional(db, histogramGlobalQuery)
+		if opts.HistogramDigests {
+			res.histogramDigest = prepareOptional(db, histogramDigestQuery)
+		}
+	}
+
+	// error summaries are available since MySQL 8.0
+	if !res.mariadb && ver >= 80000 {
+		res.errors = prepareOptional(db, errorsQuery)
+	}
+	res.errorLimit = opts.ErrorLimit
+	if res.errorLimit <= 0 {
+		res.errorLimit = DefaultErrorLimit
+	}
+
+	// stage progress is available since MySQL 5.7.5
+	if !res.mariadb && ver >= 50705 {
+		res.progress = prepareOptional(db, progressQuery)
+	}
+
+	// transaction summaries are available since MySQL 5.7.3
+	if !res.mariadb && ver >= 50703 {
+		res.transactions = prepareOptional(db, transactionsQuery)
+		res.transactionConsumer = prepareOptional(db, transactionConsumerQuery)
+		res.transactionHistory = prepareOptional(db, transactionHistoryQuery)
+		if opts.TransactionAccounts {
+			res.accountTransactions = prepareOptional(db, accountTransactionsQuery)
+		}
+	}
+
+	// memory instrumentation is available since MySQL 5.7.2
+	if ver >= 50702 {
+		res.supportsMemory = true
+		res.memory = prepareOptional(db, memoryQuery)
+	}
+	res.memoryLimit = opts.MemoryLimit
+	if res.memoryLimit <= 0 {
+		res.memoryLimit = DefaultMemoryLimit
+	}
+
+	// wait summaries are available since MySQL 5.5.3
+	if ver >= 50503 {
+		res.waits = prepareOptional(db, waitsQuery)
+		res.contention = prepareOptional(db, contentionQuery)
+	}
+	res.contentionLimit = opts.ContentionLimit
+	if res.contentionLimit <= 0 {
+		res.contentionLimit = DefaultContentionLimit
+	}
+
+	// statement digests are available since MySQL 5.6.5
+	if ver >= 50605 {
+		res.digests = prepareOptional(db, digestQuery)
+	}
+	res.digestLimit = opts.DigestLimit
+	if res.digestLimit <= 0 {
+		res.digestLimit = DefaultDigestLimit
+	}
+
+	// wsrep_on is defined only by servers with Galera support
+	res.galeraEnabled = prepareOptional(db, galeraEnabledQuery)
+	res.galeraStatus = prepareOptional(db, galeraStatusQuery)
+
+	if opts.HeartbeatTable != "" {
+		query, err := heartbeatQuery(opts.HeartbeatTable, opts.HeartbeatUTC)
+		if err != nil {
+			return nil, err
+		}
+		res.heartbeat = prepareOptional(db, query)
+		res.heartbeatServerID = opts.HeartbeatServerID
+	}
+
 	return res, nil
 
 }
@@ -167,6 +474,11 @@ func (mysql *MySQLStats) GetStatus(parseInnodb bool) (Stats, error) {
 		case strings.HasPrefix(name, "Sort_"):
 			stats["mysql_sort/"+strings.TrimPrefix(name, "Sort_")] = counter(value)
 
+		case strings.HasPrefix(name, "Rpl_semi_sync_"):
+			if metric, stat, ok := semisyncStat(name, value); ok {
+				stats["replication/semisync/"+metric] = stat
+			}
+
 		default:
 			switch name {
 			case "Qcache_hits":
@@ -195,6 +507,15 @@ func (mysql *MySQLStats) GetStatus(parseInnodb bool) (Stats, error) {
 				stats["total_threads/created"] = derive(value)
 			case "Slow_queries":
 				stats["slow/queries"] = counter(value)
+
+			case "Binlog_cache_use":
+				stats["binlog/cache_use"] = counter(value)
+			case "Binlog_cache_disk_use":
+				stats["binlog/cache_disk_use"] = counter(value)
+			case "Binlog_stmt_cache_use":
+				stats["binlog/stmt_cache_use"] = counter(value)
+			case "Binlog_stmt_cache_disk_use":
+				stats["binlog/stmt_cache_disk_use"] = counter(value)
 			}
 
 			if parseInnodb {
@@ -278,6 +599,54 @@ func (mysql *MySQLStats) GetStatus(parseInnodb bool) (Stats, error) {
 
 }
 
+// semisyncStat maps semi-synchronous replication status variable to metric
+// name relative to replication/semisync group. Source and replica variables
+// introduced in MySQL 8.0.26 are reported under the same names as their
+// master and slave counterparts.
+func semisyncStat(name string, value interface{}) (string, Stat, bool) {
+	name = strings.TrimPrefix(name, "Rpl_semi_sync_")
+
+	var role string
+	switch {
+	case strings.HasPrefix(name, "master_"), strings.HasPrefix(name, "source_"):
+		role = "master"
+	case strings.HasPrefix(name, "slave_"), strings.HasPrefix(name, "replica_"):
+		role = "slave"
+	default:
+		return "", Stat{}, false
+	}
+	name = name[strings.Index(name, "_")+1:]
+
+	switch role + "/" + name {
+	case "master/status", "slave/status":
+		return role + "/status", onOff(value), true
+	case "master/clients":
+		return "master/clients", gauge(value), true
+	case "master/wait_sessions":
+		return "master/wait_sessions", gauge(value), true
+	case "master/yes_tx":
+		return "master/yes_tx", counter(value), true
+	case "master/no_tx":
+		return "master/no_tx", counter(value), true
+	case "master/no_times":
+		return "master/no_times", counter(value), true
+	case "master/tx_waits":
+		return "master/tx_waits", counter(value), true
+	case "master/net_waits":
+		return "master/net_waits", counter(value), true
+	case "master/tx_avg_wait_time":
+		return "master/tx_avg_wait_time", gauge(value), true
+	case "master/net_avg_wait_time":
+		return "master/net_avg_wait_time", gauge(value), true
+	case "master/wait_pos_backtraverse":
+		return "master/wait_pos_backtraverse", counter(value), true
+	case "master/timefunc_failures":
+		return "master/timefunc_failures", counter(value), true
+	}
+
+	return "", Stat{}, false
+}
+
 // GetInnodb queries database for innodb statistics.
 // If query succeeded appriopriate collection of stats is returned, otherwise
 // error is returned.
@@ -429,6 +798,9 @@ func (mysql *MySQLStats) GetInnodb() (Stats, error) {
 }
 
 // GetMasterStatus queries database for statistics related to it's master role.
+// Position in binary log is counted from the first file seen, so it keeps
+// growing when binary log is rotated. When server uses GTIDs, numbers of
+// executed and purged transactions are reported for each source UUID.
 // If query succeeded appriopriate collection of stats is returned, otherwise
 // error is returned.
 func (mysql *MySQLStats) GetMasterStatus() (Stats, error) {
@@ -438,24 +810,182 @@ func (mysql *MySQLStats) GetMasterStatus() (Stats, error) {
 	}
 	defer rows.Close()
 
+	res, err := scanNamedRows(rows, nil)
+	if err != nil {
+		return nil, fmt.Errorf("master request failed: %v", err)
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("master request returned 0 rows")
+	}
+
+	row := res[0]
+
 	stats := Stats{}
 
+	position := mysql.masterPos.update(toString(row["File"]), toInt(row["Position"]), mysql.binlogSizes)
+	stats["mysql_log_position/master-bin"] = counter(position)
+
+	executedStr := toString(row["Executed_Gtid_Set"])
+	if executedStr == "" {
+		return stats, nil
+	}
+
+	executed, err := parseGtidSet(executedStr)
+	if err != nil {
+		return nil, fmt.Errorf("master request failed: %v", err)
+	}
+
+	for uuid, n := range executed.counts() {
+		stats["replication/gtid/executed/"+Dynamic("source_uuid", uuid)] = gauge(n)
+	}
+	stats["replication/gtid/executed_total"] = counter(executed.total())
+
+	var purgedStr string
+	err = mysql.db.QueryRow("SELECT @@global.gtid_purged").Scan(&purgedStr)
+	if err != nil {
+		return nil, fmt.Errorf("master request failed: %v", err)
+	}
+
+	purged, err := parseGtidSet(purgedStr)
+	if err != nil {
+		return nil, fmt.Errorf("master request failed: %v", err)
+	}
+
+	for uuid, n := range purged.counts() {
+		stats["replication/gtid/purged/"+Dynamic("source_uuid", uuid)] = gauge(n)
+	}
+
+	return stats, nil
+}
+
+// GetBinlogs queries database for inventory of binary log files.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetBinlogs() (Stats, error) {
+	sizes, err := mysql.queryBinlogSizes()
+	if err != nil {
+		return nil, fmt.Errorf("binlog request failed: %v", err)
+	}
+
+	var total int64
+	for _, size := range sizes {
+		total += size
+	}
+
+	stats := Stats{}
+	stats["binlog/files"] = gauge(len(sizes))
+	stats["binlog/size"] = gauge(total)
+
+	return stats, nil
+}
+
+// queryBinlogSizes returns sizes of binary log files accessible by file name.
+func (mysql *MySQLStats) queryBinlogSizes() (map[string]int64, error) {
+	rows, err := mysql.binlogs.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res, err := scanNamedRows(rows, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := map[string]int64{}
+	for _, row := range res {
+		sizes[toString(row["Log_name"])] = toInt(row["File_size"])
+	}
+
+	return sizes, nil
+}
+
+// binlogSizes returns sizes of binary log files or nil if they can't be read.
+func (mysql *MySQLStats) binlogSizes() map[string]int64 {
+	sizes, err := mysql.queryBinlogSizes()
+	if err != nil {
+		return nil
+	}
+	return sizes
+}
+
+// GetHeartbeat reads heartbeats written by pt-heartbeat (or compatible tool)
+// and reports how long ago they were written, which is true replication lag
+// in milliseconds. Lag is reported for each source server_id and for selected
+// (or the most recent) heartbeat.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetHeartbeat() (Stats, error) {
+	rows, err := mysql.heartbeat.Query()
+	if err != nil {
+		return nil, fmt.Errorf("heartbeat request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+
+	var lag int64
+	found := false
+
 	for rows.Next() {
-		var dummy0, position, dummy2, dummy3, dummy4 interface{}
+		var serverID, lagUs int64
 
-		err = rows.Scan(&dummy0, &position, &dummy2, &dummy3, &dummy4)
+		err = rows.Scan(&serverID, &lagUs)
 		if err != nil {
-			return nil, fmt.Errorf("master request failed: %v", err)
+			return nil, fmt.Errorf("heartbeat request failed: %v", err)
 		}
-		stats["mysql_log_position/master-bin"] = counter(position)
 
-		return stats, nil
+		lagMs := lagUs / 1000
+
+		stats["replication/heartbeat/source/"+Dynamic("server_id", strconv.FormatInt(serverID, 10))+"/lag"] = gauge(lagMs)
+
+		if (mysql.heartbeatServerID == 0 && (!found || lagMs < lag)) || serverID == mysql.heartbeatServerID {
+			lag = lagMs
+			found = true
+		}
 	}
 
-	return nil, fmt.Errorf("master request returned 0 rows")
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("heartbeat request failed: %v", err)
+	}
+
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("heartbeat request returned 0 rows")
+	}
+
+	if found {
+		stats["replication/heartbeat/lag"] = gauge(lag)
+	} else {
+		stats["replication/heartbeat/lag"] = gauge(nil)
+	}
+
+	return stats, nil
+}
+
+// heartbeatQuery returns statement reading heartbeat lag (in microseconds) of
+// each source server from given table.
+func heartbeatQuery(table string, utc bool) (string, error) {
+	if !tableNameRegexp.MatchString(table) {
+		return "", fmt.Errorf("invalid heartbeat table name: %s", table)
+	}
+
+	quoted := "`" + strings.Replace(table, ".", "`.`", 1) + "`"
+
+	now := "NOW(6)"
+	if utc {
+		now = "UTC_TIMESTAMP(6)"
+	}
+
+	return fmt.Sprintf("SELECT server_id, TIMESTAMPDIFF(MICROSECOND, ts, %s) FROM %s", now, quoted), nil
 }
 
 // GetSlaveStatus queries database for statistics related to it's slave role.
+// Columns are looked up by name, so layouts of MySQL, Percona and MariaDB
+// servers of different versions are supported. Statistics are reported for
+// each replication channel (MariaDB's named connection), metrics of default
+// channel (or the first one if there is no default) are also reported under
+// classic mysql_log_position names.
 // If query succeeded appropriate collection of stats is returned, otherwise
 // error is returned.
 func (mysql *MySQLStats) GetSlaveStatus() (Stats, error) {
@@ -465,38 +995,247 @@ func (mysql *MySQLStats) GetSlaveStatus() (Stats, error) {
 	}
 	defer rows.Close()
 
-	stats := Stats{}
-
-	cols, err := rows.Columns()
+	res, err := scanNamedRows(rows, slaveColumnAliases)
 	if err != nil {
 		return nil, fmt.Errorf("slave request failed: %v", err)
 	}
 
-	if len(cols) < 33 {
-		return nil, fmt.Errorf("slave request failed: number of columns < 33: %d", len(cols))
+	if len(res) == 0 {
+		return nil, fmt.Errorf("slave request returned 0 rows")
 	}
 
-	for rows.Next() {
+	// size of binary logs is needed only if positions are in different files,
+	// source's binary logs are assumed to be rotated at the same size as
+	// replica's ones (source's max_binlog_size isn't known to replica)
+	var binlogSize int64
+	for _, row := range res {
+		if toString(row["Master_Log_File"]) != toString(row["Relay_Master_Log_File"]) {
+			binlogSize, err = mysql.maxBinlogSize()
+			if err != nil {
+				return nil, fmt.Errorf("slave request failed: %v", err)
+			}
+			break
+		}
+	}
 
-		fields := make([]interface{}, len(cols))
-		fieldPtrs := make([]interface{}, len(fields))
+	stats := Stats{}
 
-		for i := range fieldPtrs {
-			fieldPtrs[i] = &fields[i]
+	var defaultChannel Stats
+
+	for _, row := range res {
+		channelStats, err := slaveStats(row, binlogSize)
+		if err != nil {
+			return nil, err
 		}
 
-		err = rows.Scan(fieldPtrs...)
+		channel := toString(row["Channel_Name"])
+		if channel == "" {
+			channel = defaultChannelName
+			defaultChannel = channelStats
+		}
+
+		for k, v := range channelStats {
+			stats["replication/channel/"+Dynamic("channel", channel)+"/"+k] = v
+		}
+	}
+
+	if defaultChannel == nil {
+		defaultChannel, _ = slaveStats(res[0], binlogSize)
+	}
+
+	stats["mysql_log_position/slave-read"] = defaultChannel["read_position"]
+	stats["mysql_log_position/slave-exec"] = defaultChannel["exec_position"]
+	stats["mysql_log_position/time_offset"] = defaultChannel["seconds_behind"]
+
+	return stats, nil
+}
+
+// slaveStats extracts statistics of single replication channel from row of
+// slave status. binlogSize is used to estimate distance between positions
+// in different binary log files. Columns missing in older versions are skipped.
+func slaveStats(row map[string]interface{}, binlogSize int64) (Stats, error) {
+	for _, col := range []string{"Read_Master_Log_Pos", "Exec_Master_Log_Pos", "Seconds_Behind_Master"} {
+		if _, ok := row[col]; !ok {
+			return nil, fmt.Errorf("slave request failed: column %s not found", col)
+		}
+	}
+
+	stats := Stats{}
+	stats["read_position"] = counter(row["Read_Master_Log_Pos"])
+	stats["exec_position"] = counter(row["Exec_Master_Log_Pos"])
+	stats["seconds_behind"] = gauge(row["Seconds_Behind_Master"])
+
+	for col, value := range row {
+		switch col {
+		case "Slave_IO_Running":
+			stats["io_running"] = yesNo(value)
+		case "Slave_SQL_Running":
+			stats["sql_running"] = yesNo(value)
+		case "Last_IO_Errno":
+			stats["last_io_errno"] = gauge(value)
+		case "Last_SQL_Errno":
+			stats["last_sql_errno"] = gauge(value)
+		case "Relay_Log_Space":
+			stats["relay_log_space"] = gauge(value)
+		case "SQL_Delay":
+			stats["sql_delay"] = gauge(value)
+		case "SQL_Remaining_Delay":
+			stats["sql_remaining_delay"] = gauge(value)
+		case "Slave_SQL_Running_State":
+			stats["sql_running_state"] = text(value)
+		case "Connect_Retry":
+			stats["connect_retry_interval"] = gauge(value)
+		case "Master_Retry_Count":
+			stats["max_retry_count"] = gauge(value)
+		case "Connects_Tried":
+			stats["connects_tried"] = counter(value)
+		}
+	}
+
+	retrievedStr := toString(row["Retrieved_Gtid_Set"])
+	if retrievedStr != "" {
+		retrieved, err := parseGtidSet(retrievedStr)
 		if err != nil {
 			return nil, fmt.Errorf("slave request failed: %v", err)
 		}
-		stats["mysql_log_position/slave-read"] = counter(fields[slaveReadMasterIDX])
-		stats["mysql_log_position/slave-exec"] = counter(fields[slaveExecMasterLogPosIDX])
-		stats["mysql_log_position/time_offset"] = gauge(fields[slaveSecondsBehindIDX])
 
-		return stats, nil
+		executed, err := parseGtidSet(toString(row["Executed_Gtid_Set"]))
+		if err != nil {
+			return nil, fmt.Errorf("slave request failed: %v", err)
+		}
+
+		for uuid, n := range retrieved.counts() {
+			stats["gtid_retrieved/"+Dynamic("source_uuid", uuid)] = gauge(n)
+		}
+		stats["gtid_behind"] = gauge(retrieved.countMissing(executed))
+	}
+
+	if _, ok := row["Master_Log_File"]; ok {
+		distance, ok := logDistance(
+			toString(row["Master_Log_File"]), toInt(row["Read_Master_Log_Pos"]),
+			toString(row["Relay_Master_Log_File"]), toInt(row["Exec_Master_Log_Pos"]),
+			binlogSize)
+		if ok {
+			stats["read_exec_distance"] = gauge(distance)
+		} else {
+			stats["read_exec_distance"] = gauge(nil)
+		}
+	}
+
+	return stats, nil
+}
+
+// logDistance calculates number of bytes between two positions in binary log.
+// When positions are in different files, all files between them are assumed
+// to be of fileSize bytes. ok is false when files are unknown or the
+// distance can't be determined.
+func logDistance(toFile string, toPos int64, fromFile string, fromPos int64, fileSize int64) (distance int64, ok bool) {
+	if toFile == "" || fromFile == "" {
+		return 0, false
+	}
+
+	if toFile == fromFile {
+		return toPos - fromPos, true
+	}
+
+	toSeq, toOk := logFileSequence(toFile)
+	fromSeq, fromOk := logFileSequence(fromFile)
+	if !toOk || !fromOk || toSeq < fromSeq || fileSize <= 0 {
+		return 0, false
+	}
+
+	// rest of the first file, full files in between and part of the last one
+	return (fileSize - fromPos) + (toSeq-fromSeq-1)*fileSize + toPos, true
+}
+
+// logFileSequence extracts sequence number of binary log file, ex. 12 is
+// returned for mysql-bin.000012.
+func logFileSequence(file string) (int64, bool) {
+	idx := strings.LastIndex(file, ".")
+	if idx < 0 {
+		return 0, false
 	}
+	seq, err := strconv.ParseInt(file[idx+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
 
-	return nil, fmt.Errorf("slave request returned 0 rows")
+// binlogPosition tracks position in binary log across file rotations.
+// binlogHeaderSize is size of magic number which starts every binary log file,
+// positions in file are counted from the beginning of the file including it.
+const binlogHeaderSize = 4
+
+type binlogPosition struct {
+	mutex sync.Mutex
+	file  string
+	pos   int64
+	// number of bytes in files preceding the current one
+	offset int64
+}
+
+// update records current position in binary log and returns number of bytes
+// written since the beginning of the first file seen. On rotation sizes is
+// called to learn sizes of files written since the last update. When sizes of
+// files are unavailable the last known position is used as size of previous
+// file and files in between are not accounted. Headers of files following the
+// first one are not counted as written bytes, so position doesn't jump on
+// rotation.
+func (bp *binlogPosition) update(file string, pos int64, sizes func() map[string]int64) int64 {
+	bp.mutex.Lock()
+	defer bp.mutex.Unlock()
+
+	if bp.file != "" && file != bp.file {
+		known := sizes()
+
+		if size, ok := known[bp.file]; ok {
+			bp.offset += size - binlogHeaderSize
+		} else {
+			bp.offset += bp.pos - binlogHeaderSize
+		}
+
+		prevSeq, prevOk := logFileSequence(bp.file)
+		curSeq, curOk := logFileSequence(file)
+		if prevOk && curOk {
+			for name, size := range known {
+				if seq, ok := logFileSequence(name); ok && seq > prevSeq && seq < curSeq {
+					bp.offset += size - binlogHeaderSize
+				}
+			}
+		}
+	}
+
+	bp.file = file
+	bp.pos = pos
+
+	return bp.offset + pos
+}
+
+// maxBinlogSize returns size at which binary logs of this server are rotated.
+func (mysql *MySQLStats) maxBinlogSize() (int64, error) {
+	var size int64
+	err := mysql.db.QueryRow("SELECT @@global.max_binlog_size").Scan(&size)
+	return size, err
+}
+
+// slaveStatusQuery returns statement reporting status of all replication
+// channels which is appropriate for server flavor and version.
+func (mysql *MySQLStats) slaveStatusQuery() string {
+	if mysql.mariadb {
+		if mysql.version >= 100501 {
+			return "SHOW ALL REPLICAS STATUS"
+		}
+		// multi-source replication is available since MariaDB 10.0
+		if mysql.version >= 100000 {
+			return "SHOW ALL SLAVES STATUS"
+		}
+		return "SHOW SLAVE STATUS"
+	}
+	if mysql.version >= 80022 {
+		return "SHOW REPLICA STATUS"
+	}
+	return "SHOW SLAVE STATUS"
 }
 
 // Close closes sql resources.
@@ -513,17 +1252,98 @@ func parseVersion(s string) uint {
 	return a*10000 + b*100 + c
 }
 
+// isMariaDB checks if version string reported by server belongs to MariaDB.
+func isMariaDB(s string) bool {
+	return strings.Contains(strings.ToLower(s), "mariadb")
+}
+
+// scanNamedRows reads all rows into maps accessible by column name. Names
+// found in aliases are replaced with associated names.
+func scanNamedRows(rows *sql.Rows, aliases map[string]string) ([]map[string]interface{}, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, col := range cols {
+		if alias, ok := aliases[col]; ok {
+			cols[i] = alias
+		}
+	}
+
+	res := []map[string]interface{}{}
+
+	for rows.Next() {
+		fields := make([]interface{}, len(cols))
+		fieldPtrs := make([]interface{}, len(fields))
+
+		for i := range fieldPtrs {
+			fieldPtrs[i] = &fields[i]
+		}
+
+		err = rows.Scan(fieldPtrs...)
+		if err != nil {
+			return nil, err
+		}
+
+		row := map[string]interface{}{}
+		for i, col := range cols {
+			row[col] = fields[i]
+		}
+		res = append(res, row)
+	}
+
+	return res, rows.Err()
+}
+
 // for mocking
 var sqlOpen = func(driverName, dataSourceName string) (*sql.DB, error) {
 	return sql.Open(driverName, dataSourceName)
 }
 
+// toString converts value to string regardless of underlying type.
+// Null value is converted to empty string.
+func toString(ifc interface{}) string {
+	switch v := ifc.(type) {
+	case nil:
+		return ""
+	case []uint8:
+		return string(v)
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", ifc)
+}
+
+// Dynamic returns metric name segment which represents dynamic namespace
+// element called name and holding given value. Slashes in value are replaced
+// with underscores as they separate segments of metric name.
+func Dynamic(name, value string) string {
+	return "[" + name + "=" + strings.Replace(value, "/", "_", -1) + "]"
+}
+
+// ParseDynamic extracts name and value of dynamic namespace element from
+// metric name segment made by Dynamic. ok is false if segment is static.
+func ParseDynamic(segment string) (name, value string, ok bool) {
+	if !strings.HasPrefix(segment, "[") || !strings.HasSuffix(segment, "]") {
+		return "", "", false
+	}
+	parts := strings.SplitN(segment[1:len(segment)-1], "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
 // toInt converts value to regardless of underlying type.
 // Can handle (u)int[8/16/32/64] and varchar string if it's numeric.
 func toInt(ifc interface{}) int64 {
 	if ifc == nil {
 		return 0
 	}
+	if str, isStr := ifc.(string); isStr {
+		ifc = []uint8(str)
+	}
 	if bs, isBs := ifc.([]uint8); isBs {
 		v, err := strconv.Atoi(string(bs))
 		if err != nil {
@@ -544,6 +1364,27 @@ func derive(val interface{}) Stat {
 	return Stat{Value: toInt(val), Type: Derive, IsNull: val == nil}
 }
 
+// text fills Stat structure appropriately for text type.
+func text(val interface{}) Stat {
+	return Stat{Text: toString(val), Type: Text, IsNull: val == nil}
+}
+
+// yesNo fills Stat structure of gauge type with 1 if val is "Yes" and 0 otherwise.
+func yesNo(val interface{}) Stat {
+	if strings.EqualFold(toString(val), "Yes") {
+		return gauge(1)
+	}
+	return Stat{Value: 0, Type: Gauge, IsNull: val == nil}
+}
+
+// onOff fills Stat structure with 1 for ON values and 0 otherwise.
+func onOff(val interface{}) Stat {
+	if strings.EqualFold(toString(val), "ON") {
+		return gauge(1)
+	}
+	return Stat{Value: 0, Type: Gauge, IsNull: val == nil}
+}
+
 // gauge fills Stat structure appropriately for gauge type.
 func gauge(val interface{}) Stat {
 	return Stat{Value: toInt(val), Type: Gauge, IsNull: val == nil}
@@ -123,7 +123,7 @@ func TestMNew(t *testing.T) {
 			Convey("when version is returned", func() {
 				tesingNew(mock, "5.6.5-ubu", true, true, 0)
 				mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("5.6.5-ubu"))
-				_, dut := New(testingConnectionString)
+				_, dut := New(testingConnectionString, Options{})
 				Convey("no error is returned", func() {
 					So(dut, ShouldBeNil)
 					assert(mock, t)
@@ -135,7 +135,7 @@ func TestMNew(t *testing.T) {
 			Convey("when version is not returned", func() {
 
 				tesingNew(mock, fmt.Errorf("xxx"), true, true, 0)
-				_, dut := New(testingConnectionString)
+				_, dut := New(testingConnectionString, Options{})
 
 				Convey("error is returned", func() {
 
@@ -152,7 +152,7 @@ func TestMNew(t *testing.T) {
 			Convey("when version >= 5.0.2", func() {
 				tesingNew(mock, "5.6.5-ubu", true, true, 1)
 				mock.ExpectPrepare("SHOW GLOBAL STATUS")
-				New(testingConnectionString)
+				New(testingConnectionString, Options{})
 
 				Convey("show global status is prepared", func() {
 
@@ -163,11 +163,50 @@ func TestMNew(t *testing.T) {
 			})
 		})
 
+		Convey("prepares replica status statement", func() {
+
+			Convey("SHOW SLAVE STATUS for MySQL < 8.0.22", func() {
+				tesingNew(mock, "8.0.21", true, true, 4)
+				mock.ExpectPrepare("SHOW SLAVE STATUS")
+				New(testingConnectionString, Options{})
+				assert(mock, t)
+			})
+
+			Convey("SHOW REPLICA STATUS for MySQL >= 8.0.22", func() {
+				tesingNew(mock, "8.0.22", true, true, 4)
+				mock.ExpectPrepare("SHOW REPLICA STATUS")
+				New(testingConnectionString, Options{})
+				assert(mock, t)
+			})
+
+			Convey("SHOW SLAVE STATUS for MariaDB < 10.0", func() {
+				tesingNew(mock, "5.5.68-MariaDB", true, true, 2, 4)
+				mock.ExpectPrepare("SHOW SLAVE STATUS")
+				New(testingConnectionString, Options{})
+				assert(mock, t)
+			})
+
+			Convey("SHOW ALL SLAVES STATUS for MariaDB >= 10.0 and < 10.5.1", func() {
+				tesingNew(mock, "10.4.12-MariaDB-log", true, true, 4)
+				mock.ExpectPrepare("SHOW ALL SLAVES STATUS")
+				New(testingConnectionString, Options{})
+				assert(mock, t)
+			})
+
+			Convey("SHOW ALL REPLICAS STATUS for MariaDB >= 10.5.1", func() {
+				tesingNew(mock, "10.5.8-MariaDB", true, true, 4)
+				mock.ExpectPrepare("SHOW ALL REPLICAS STATUS")
+				New(testingConnectionString, Options{})
+				assert(mock, t)
+			})
+
+		})
+
 		Convey("if mysql version >= 5.6.0", func() {
 
 			tesingNew(mock, "5.6.5-ubu", true, true, 2)
 			mock.ExpectPrepare(".*innodb.*")
-			New(testingConnectionString)
+			New(testingConnectionString, Options{})
 
 			Convey("innodb query is prepared", func() {
 
@@ -181,7 +220,7 @@ func TestMNew(t *testing.T) {
 
 			tesingNew(mock, "5.5.5-ubu", true, true, 2)
 			mock.ExpectPrepare(".*innodb.*").WillReturnError(smthErr)
-			_, dut := New(testingConnectionString)
+			_, dut := New(testingConnectionString, Options{})
 
 			Convey("innodb query is not prepared", func() {
 
@@ -197,3 +236,476 @@ func TestMNew(t *testing.T) {
 
 	})
 }
+
+func TestSlaveStats(t *testing.T) {
+	Convey("slaveStats", t, func() {
+
+		Convey("reads positions by column name", func() {
+
+			row := map[string]interface{}{
+				"Read_Master_Log_Pos":   int64(200),
+				"Exec_Master_Log_Pos":   int64(100),
+				"Seconds_Behind_Master": nil,
+			}
+
+			dut, err := slaveStats(row, 0)
+
+			So(err, ShouldBeNil)
+			So(dut["read_position"].Value, ShouldEqual, 200)
+			So(dut["exec_position"].Value, ShouldEqual, 100)
+			So(dut["seconds_behind"].IsNull, ShouldBeTrue)
+
+		})
+
+		Convey("reads replica health", func() {
+
+			row := map[string]interface{}{
+				"Master_Log_File":         []byte("mysql-bin.000012"),
+				"Read_Master_Log_Pos":     int64(200),
+				"Relay_Master_Log_File":   []byte("mysql-bin.000012"),
+				"Exec_Master_Log_Pos":     int64(150),
+				"Seconds_Behind_Master":   nil,
+				"Slave_IO_Running":        []byte("Connecting"),
+				"Slave_SQL_Running":       []byte("Yes"),
+				"Last_IO_Errno":           int64(2003),
+				"Relay_Log_Space":         int64(4096),
+				"SQL_Remaining_Delay":     nil,
+				"Slave_SQL_Running_State": []byte("Slave has read all relay log; waiting for more updates"),
+				"Master_Retry_Count":      int64(86400),
+				"Connects_Tried":          int64(7),
+			}
+
+			dut, err := slaveStats(row, 0)
+
+			So(err, ShouldBeNil)
+			So(dut["io_running"].Value, ShouldEqual, 0)
+			So(dut["sql_running"].Value, ShouldEqual, 1)
+			So(dut["last_io_errno"].Value, ShouldEqual, 2003)
+			So(dut["relay_log_space"].Value, ShouldEqual, 4096)
+			So(dut["sql_remaining_delay"].IsNull, ShouldBeTrue)
+			So(dut["sql_running_state"].Type, ShouldEqual, Text)
+			So(dut["sql_running_state"].Text, ShouldEqual, "Slave has read all relay log; waiting for more updates")
+			So(dut["max_retry_count"].Value, ShouldEqual, 86400)
+			So(dut["connects_tried"].Value, ShouldEqual, 7)
+			So(dut["connects_tried"].Type, ShouldEqual, Counter)
+			So(dut["read_exec_distance"].Value, ShouldEqual, 50)
+
+			Convey("skipping columns missing in older versions", func() {
+				So(dut, ShouldNotContainKey, "sql_delay")
+				So(dut, ShouldNotContainKey, "connect_retry_interval")
+			})
+
+		})
+
+		Convey("reads transactions retrieved but not executed", func() {
+
+			row := map[string]interface{}{
+				"Read_Master_Log_Pos":   int64(200),
+				"Exec_Master_Log_Pos":   int64(150),
+				"Seconds_Behind_Master": int64(0),
+				"Retrieved_Gtid_Set":    []byte("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-100"),
+				"Executed_Gtid_Set":     []byte("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-90,\n8a94f357-aab4-11df-86ab-c80aa9429562:1-5"),
+			}
+
+			dut, err := slaveStats(row, 0)
+
+			So(err, ShouldBeNil)
+			So(dut["gtid_retrieved/[source_uuid=3e11fa47-71ca-11e1-9e33-c80aa9429562]"].Value, ShouldEqual, 100)
+			So(dut["gtid_behind"].Value, ShouldEqual, 10)
+
+		})
+
+		Convey("returns error when column is missing", func() {
+
+			_, err := slaveStats(map[string]interface{}{"Read_Master_Log_Pos": int64(200)}, 0)
+
+			So(err, ShouldNotBeNil)
+
+		})
+
+	})
+}
+
+func TestGetMasterStatus(t *testing.T) {
+	Convey("GetMasterStatus", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		Convey("reports binary log position", func() {
+
+			mock.ExpectPrepare("SHOW MASTER STATUS").ExpectQuery().WillReturnRows(
+				sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB"}).
+					AddRow("mysql-bin.000003", 73, "", ""))
+
+			stmt, _ := db.Prepare("SHOW MASTER STATUS")
+			sut := &MySQLStats{db: db, master: stmt}
+
+			dut, err := sut.GetMasterStatus()
+
+			So(err, ShouldBeNil)
+			So(dut["mysql_log_position/master-bin"].Value, ShouldEqual, 73)
+			So(dut, ShouldNotContainKey, "replication/gtid/executed_total")
+
+		})
+
+		Convey("reports executed and purged transactions when gtids are used", func() {
+
+			mock.ExpectPrepare("SHOW MASTER STATUS").ExpectQuery().WillReturnRows(
+				sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}).
+					AddRow("mysql-bin.000003", 73, "", "", "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-100"))
+			mock.ExpectQuery("SELECT @@global.gtid_purged").WillReturnRows(
+				sqlmock.NewRows([]string{"@@global.gtid_purged"}).AddRow("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-40"))
+
+			stmt, _ := db.Prepare("SHOW MASTER STATUS")
+			sut := &MySQLStats{db: db, master: stmt}
+
+			dut, err := sut.GetMasterStatus()
+
+			So(err, ShouldBeNil)
+			So(dut["replication/gtid/executed/[source_uuid=3e11fa47-71ca-11e1-9e33-c80aa9429562]"].Value, ShouldEqual, 100)
+			So(dut["replication/gtid/executed_total"].Value, ShouldEqual, 100)
+			So(dut["replication/gtid/executed_total"].Type, ShouldEqual, Counter)
+			So(dut["replication/gtid/purged/[source_uuid=3e11fa47-71ca-11e1-9e33-c80aa9429562]"].Value, ShouldEqual, 40)
+
+		})
+
+	})
+}
+
+func TestGetBinlogs(t *testing.T) {
+	Convey("GetBinlogs", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		Convey("reports number and total size of binary logs", func() {
+
+			mock.ExpectPrepare("SHOW BINARY LOGS").ExpectQuery().WillReturnRows(
+				sqlmock.NewRows([]string{"Log_name", "File_size", "Encrypted"}).
+					AddRow("mysql-bin.000001", 1000, "No").
+					AddRow("mysql-bin.000002", 500, "No"))
+
+			sut := &MySQLStats{db: db, binlogs: prepareOptional(db, "SHOW BINARY LOGS")}
+
+			dut, err := sut.GetBinlogs()
+
+			So(err, ShouldBeNil)
+			So(dut["binlog/files"].Value, ShouldEqual, 2)
+			So(dut["binlog/size"].Value, ShouldEqual, 1500)
+
+		})
+
+		Convey("returns error when statement was not prepared", func() {
+
+			mock.ExpectPrepare("SHOW BINARY LOGS").WillReturnError(fmt.Errorf("access denied"))
+
+			sut := &MySQLStats{db: db, binlogs: prepareOptional(db, "SHOW BINARY LOGS")}
+
+			_, err := sut.GetBinlogs()
+
+			So(err, ShouldNotBeNil)
+
+		})
+
+	})
+}
+
+func TestBinlogPosition(t *testing.T) {
+	Convey("binlogPosition", t, func() {
+
+		sut := &binlogPosition{}
+
+		sizes := func() map[string]int64 {
+			return map[string]int64{"bin.000001": 1000, "bin.000002": 2000, "bin.000003": 3000, "bin.000004": 100}
+		}
+
+		So(sut.update("bin.000001", 400, sizes), ShouldEqual, 400)
+		So(sut.update("bin.000001", 900, sizes), ShouldEqual, 900)
+
+		Convey("keeps growing when file is rotated", func() {
+
+			So(sut.update("bin.000002", 100, sizes), ShouldEqual, 1000-binlogHeaderSize+100)
+
+		})
+
+		Convey("accounts for files written between updates", func() {
+
+			So(sut.update("bin.000004", 50, sizes), ShouldEqual, 1000+2000+3000-3*binlogHeaderSize+50)
+
+		})
+
+		Convey("uses last known position when sizes are unavailable", func() {
+
+			So(sut.update("bin.000002", 100, func() map[string]int64 { return nil }), ShouldEqual, 900-binlogHeaderSize+100)
+
+		})
+
+	})
+}
+
+func TestGetHeartbeat(t *testing.T) {
+	Convey("GetHeartbeat", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		query, _ := heartbeatQuery("percona.heartbeat", false)
+
+		mock.ExpectPrepare("SELECT server_id, .* FROM `percona`.`heartbeat`").ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"server_id", "lag"}).
+				AddRow(1, 1500000).
+				AddRow(2, 250000))
+
+		sut := &MySQLStats{db: db, heartbeat: prepareOptional(db, query)}
+
+		Convey("reports lag of each source in milliseconds", func() {
+
+			dut, err := sut.GetHeartbeat()
+
+			So(err, ShouldBeNil)
+			So(dut["replication/heartbeat/source/[server_id=1]/lag"].Value, ShouldEqual, 1500)
+			So(dut["replication/heartbeat/source/[server_id=2]/lag"].Value, ShouldEqual, 250)
+
+		})
+
+		Convey("reports lag of the most recent heartbeat", func() {
+
+			dut, _ := sut.GetHeartbeat()
+
+			So(dut["replication/heartbeat/lag"].Value, ShouldEqual, 250)
+
+		})
+
+		Convey("reports lag of selected source", func() {
+
+			sut.heartbeatServerID = 1
+
+			dut, _ := sut.GetHeartbeat()
+
+			So(dut["replication/heartbeat/lag"].Value, ShouldEqual, 1500)
+
+		})
+
+		Convey("reports null lag when selected source has no heartbeat", func() {
+
+			sut.heartbeatServerID = 3
+
+			dut, _ := sut.GetHeartbeat()
+
+			So(dut["replication/heartbeat/lag"].IsNull, ShouldBeTrue)
+
+		})
+
+	})
+}
+
+func TestHeartbeatQuery(t *testing.T) {
+	Convey("heartbeatQuery", t, func() {
+
+		Convey("quotes table name", func() {
+			dut, err := heartbeatQuery("percona.heartbeat", false)
+			So(err, ShouldBeNil)
+			So(dut, ShouldContainSubstring, "FROM `percona`.`heartbeat`")
+			So(dut, ShouldContainSubstring, "NOW(6)")
+		})
+
+		Convey("compares with UTC time when heartbeats are in UTC", func() {
+			dut, _ := heartbeatQuery("heartbeat", true)
+			So(dut, ShouldContainSubstring, "UTC_TIMESTAMP(6)")
+		})
+
+		Convey("rejects invalid table name", func() {
+			_, err := heartbeatQuery("heartbeat; DROP TABLE x", false)
+			So(err, ShouldNotBeNil)
+		})
+
+	})
+}
+
+func TestSemisyncStat(t *testing.T) {
+	Convey("semisyncStat", t, func() {
+
+		Convey("maps master status", func() {
+			name, stat, ok := semisyncStat("Rpl_semi_sync_master_status", "ON")
+			So(ok, ShouldBeTrue)
+			So(name, ShouldEqual, "master/status")
+			So(stat.Value, ShouldEqual, 1)
+
+			_, stat, _ = semisyncStat("Rpl_semi_sync_master_status", "OFF")
+			So(stat.Value, ShouldEqual, 0)
+		})
+
+		Convey("maps source variables to master metrics", func() {
+			name, stat, ok := semisyncStat("Rpl_semi_sync_source_no_times", "3")
+			So(ok, ShouldBeTrue)
+			So(name, ShouldEqual, "master/no_times")
+			So(stat.Type, ShouldEqual, Counter)
+			So(stat.Value, ShouldEqual, 3)
+		})
+
+		Convey("maps replica variables to slave metrics", func() {
+			name, stat, ok := semisyncStat("Rpl_semi_sync_replica_status", "ON")
+			So(ok, ShouldBeTrue)
+			So(name, ShouldEqual, "slave/status")
+			So(stat.Value, ShouldEqual, 1)
+		})
+
+		Convey("maps average wait times as gauges", func() {
+			name, stat, _ := semisyncStat("Rpl_semi_sync_master_tx_avg_wait_time", "1250")
+			So(name, ShouldEqual, "master/tx_avg_wait_time")
+			So(stat.Type, ShouldEqual, Gauge)
+			So(stat.Value, ShouldEqual, 1250)
+		})
+
+		Convey("ignores unknown variables", func() {
+			_, _, ok := semisyncStat("Rpl_semi_sync_slave_send_ack", "1")
+			So(ok, ShouldBeFalse)
+		})
+
+	})
+}
+
+func TestLogDistance(t *testing.T) {
+	Convey("logDistance", t, func() {
+
+		Convey("subtracts positions in the same file", func() {
+			dut, ok := logDistance("bin.000003", 900, "bin.000003", 400, 1000)
+			So(ok, ShouldBeTrue)
+			So(dut, ShouldEqual, 500)
+		})
+
+		Convey("accounts for files between positions", func() {
+			// 600 left in bin.000001, whole bin.000002 and 100 in bin.000003
+			dut, ok := logDistance("bin.000003", 100, "bin.000001", 400, 1000)
+			So(ok, ShouldBeTrue)
+			So(dut, ShouldEqual, 1700)
+		})
+
+		Convey("fails when files are unknown", func() {
+			_, ok := logDistance("", 100, "bin.000001", 400, 1000)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("fails when file names have no sequence number", func() {
+			_, ok := logDistance("bin", 100, "bin.000001", 400, 1000)
+			So(ok, ShouldBeFalse)
+		})
+
+	})
+}
+
+func TestGetSlaveStatus(t *testing.T) {
+	Convey("GetSlaveStatus", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		columns := []string{"Channel_Name", "Read_Source_Log_Pos", "Exec_Source_Log_Pos", "Seconds_Behind_Source"}
+
+		Convey("reports each replication channel", func() {
+
+			mock.ExpectPrepare("SHOW REPLICA STATUS").ExpectQuery().WillReturnRows(
+				sqlmock.NewRows(columns).
+					AddRow("", 200, 100, 5).
+					AddRow("src/2", 400, 300, 7))
+
+			stmt, _ := db.Prepare("SHOW REPLICA STATUS")
+			sut := &MySQLStats{slave: stmt}
+
+			dut, err := sut.GetSlaveStatus()
+
+			So(err, ShouldBeNil)
+			So(dut["replication/channel/[channel=default]/read_position"].Value, ShouldEqual, 200)
+			So(dut["replication/channel/[channel=src_2]/read_position"].Value, ShouldEqual, 400)
+			So(dut["replication/channel/[channel=src_2]/seconds_behind"].Value, ShouldEqual, 7)
+
+			Convey("and default channel under classic names", func() {
+				So(dut["mysql_log_position/slave-read"].Value, ShouldEqual, 200)
+				So(dut["mysql_log_position/slave-exec"].Value, ShouldEqual, 100)
+				So(dut["mysql_log_position/time_offset"].Value, ShouldEqual, 5)
+			})
+
+		})
+
+		Convey("reports MariaDB named connections", func() {
+
+			mock.ExpectPrepare("SHOW ALL SLAVES STATUS").ExpectQuery().WillReturnRows(
+				sqlmock.NewRows([]string{"Connection_name", "Read_Master_Log_Pos", "Exec_Master_Log_Pos", "Seconds_Behind_Master"}).
+					AddRow("east", 200, 100, 5))
+
+			stmt, _ := db.Prepare("SHOW ALL SLAVES STATUS")
+			sut := &MySQLStats{slave: stmt, mariadb: true}
+
+			dut, err := sut.GetSlaveStatus()
+
+			So(err, ShouldBeNil)
+			So(dut["replication/channel/[channel=east]/exec_position"].Value, ShouldEqual, 100)
+			So(dut["mysql_log_position/slave-exec"].Value, ShouldEqual, 100)
+
+		})
+
+		Convey("returns error when server is not a slave", func() {
+
+			mock.ExpectPrepare("SHOW SLAVE STATUS").ExpectQuery().WillReturnRows(sqlmock.NewRows(columns))
+
+			stmt, _ := db.Prepare("SHOW SLAVE STATUS")
+			sut := &MySQLStats{slave: stmt}
+
+			_, err := sut.GetSlaveStatus()
+
+			So(err, ShouldNotBeNil)
+
+		})
+
+	})
+}
+
+func TestDynamic(t *testing.T) {
+	Convey("Dynamic namespace elements", t, func() {
+
+		Convey("are formatted with name and value", func() {
+			So(Dynamic("channel", "east"), ShouldEqual, "[channel=east]")
+		})
+
+		Convey("have slashes replaced in value", func() {
+			So(Dynamic("file", "/var/lib/mysql/ibdata1"), ShouldEqual, "[file=_var_lib_mysql_ibdata1]")
+		})
+
+		Convey("are parsed back", func() {
+			name, value, ok := ParseDynamic(Dynamic("channel", "a=b"))
+			So(ok, ShouldBeTrue)
+			So(name, ShouldEqual, "channel")
+			So(value, ShouldEqual, "a=b")
+		})
+
+		Convey("are not parsed from static segment", func() {
+			_, _, ok := ParseDynamic("slave-read")
+			So(ok, ShouldBeFalse)
+		})
+
+	})
+}
+
+func TestScanNamedRows(t *testing.T) {
+	Convey("scanNamedRows", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectQuery("SHOW REPLICA STATUS").WillReturnRows(
+			sqlmock.NewRows([]string{"Replica_IO_State", "Read_Source_Log_Pos"}).
+				AddRow("Waiting for source to send event", 200).
+				AddRow("Connecting to source", 300))
+
+		rows, _ := db.Query("SHOW REPLICA STATUS")
+		dut, err := scanNamedRows(rows, slaveColumnAliases)
+
+		Convey("returns all rows", func() {
+			So(err, ShouldBeNil)
+			So(dut, ShouldHaveLength, 2)
+		})
+
+		Convey("accessible by aliased column names", func() {
+			So(dut[0]["Read_Master_Log_Pos"], ShouldEqual, 200)
+			So(dut[1]["Read_Master_Log_Pos"], ShouldEqual, 300)
+			So(dut[0], ShouldNotContainKey, "Read_Source_Log_Pos")
+		})
+
+	})
+}
@@ -0,0 +1,78 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+)
+
+// tableIOQuery selects I/O wait statistics of tables, timers are converted
+// from picoseconds to microseconds.
+const tableIOQuery = `SELECT OBJECT_SCHEMA, OBJECT_NAME,
+	COUNT_READ, SUM_TIMER_READ DIV 1000000, COUNT_WRITE, SUM_TIMER_WRITE DIV 1000000,
+	COUNT_FETCH, SUM_TIMER_FETCH DIV 1000000, COUNT_INSERT, SUM_TIMER_INSERT DIV 1000000,
+	COUNT_UPDATE, SUM_TIMER_UPDATE DIV 1000000, COUNT_DELETE, SUM_TIMER_DELETE DIV 1000000
+	FROM performance_schema.table_io_waits_summary_by_table
+	WHERE OBJECT_TYPE = 'TABLE'`
+
+// tableIOOperations lists operations in order of their columns in tableIOQuery.
+var tableIOOperations = []string{"read", "write", "fetch", "insert", "update", "delete"}
+
+// GetTableIO queries database for I/O wait statistics of tables selected by
+// table filter.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetTableIO() (Stats, error) {
+	rows, err := mysql.tableIO.Query()
+	if err != nil {
+		return nil, fmt.Errorf("table io request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+
+	for rows.Next() {
+		var schema, table string
+		values := make([]interface{}, 2*len(tableIOOperations))
+
+		dst := []interface{}{&schema, &table}
+		for i := range values {
+			dst = append(dst, &values[i])
+		}
+
+		err = rows.Scan(dst...)
+		if err != nil {
+			return nil, fmt.Errorf("table io request failed: %v", err)
+		}
+
+		if !mysql.tables.match(schema, table) {
+			continue
+		}
+
+		prefix := "table_io/" + Dynamic("schema", schema) + "/" + Dynamic("table", table) + "/"
+
+		for i, op := range tableIOOperations {
+			stats[prefix+op] = counter(values[2*i])
+			stats[prefix+op+"_latency"] = counter(values[2*i+1])
+		}
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,74 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetTableIO(t *testing.T) {
+	Convey("GetTableIO", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectPrepare("table_io_waits_summary_by_table").ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"OBJECT_SCHEMA", "OBJECT_NAME",
+				"COUNT_READ", "SUM_TIMER_READ", "COUNT_WRITE", "SUM_TIMER_WRITE",
+				"COUNT_FETCH", "SUM_TIMER_FETCH", "COUNT_INSERT", "SUM_TIMER_INSERT",
+				"COUNT_UPDATE", "SUM_TIMER_UPDATE", "COUNT_DELETE", "SUM_TIMER_DELETE"}).
+				AddRow("shop", "orders", 100, 1000, 30, 600, 100, 1000, 10, 200, 15, 300, 5, 100).
+				AddRow("mysql", "user", 2, 10, 0, 0, 2, 10, 0, 0, 0, 0, 0, 0))
+
+		sut := &MySQLStats{db: db, tableIO: prepareOptional(db, tableIOQuery)}
+
+		Convey("reports counts and latencies of each table", func() {
+
+			dut, err := sut.GetTableIO()
+
+			So(err, ShouldBeNil)
+			So(dut["table_io/[schema=shop]/[table=orders]/read"].Value, ShouldEqual, 100)
+			So(dut["table_io/[schema=shop]/[table=orders]/read"].Type, ShouldEqual, Counter)
+			So(dut["table_io/[schema=shop]/[table=orders]/write_latency"].Value, ShouldEqual, 600)
+			So(dut["table_io/[schema=shop]/[table=orders]/insert"].Value, ShouldEqual, 10)
+			So(dut["table_io/[schema=shop]/[table=orders]/update_latency"].Value, ShouldEqual, 300)
+			So(dut["table_io/[schema=shop]/[table=orders]/delete"].Value, ShouldEqual, 5)
+			So(dut, ShouldContainKey, "table_io/[schema=mysql]/[table=user]/fetch")
+
+		})
+
+		Convey("skips tables not selected by filter", func() {
+
+			sut.tables, _ = newTableFilter("", "^mysql$", "", "")
+
+			dut, err := sut.GetTableIO()
+
+			So(err, ShouldBeNil)
+			So(dut, ShouldContainKey, "table_io/[schema=shop]/[table=orders]/fetch")
+			So(dut, ShouldNotContainKey, "table_io/[schema=mysql]/[table=user]/fetch")
+
+		})
+
+	})
+}
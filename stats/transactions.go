@@ -0,0 +1,260 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"sync"
+)
+
+// transaction summaries don't tell committed transactions from rolled back
+// ones, so ends of transactions are counted from transaction history; timers
+// are converted from picoseconds to microseconds
+const (
+	transactionsQuery = `SELECT COUNT_STAR, SUM_TIMER_WAIT DIV 1000000, AVG_TIMER_WAIT DIV 1000000,
+	COUNT_READ_WRITE, SUM_TIMER_READ_WRITE DIV 1000000, COUNT_READ_ONLY, SUM_TIMER_READ_ONLY DIV 1000000
+	FROM performance_schema.events_transactions_summary_global_by_event_name
+	WHERE EVENT_NAME = 'transaction'`
+
+	accountTransactionsQuery = `SELECT USER, HOST, COUNT_STAR, SUM_TIMER_WAIT DIV 1000000, AVG_TIMER_WAIT DIV 1000000,
+	COUNT_READ_WRITE, SUM_TIMER_READ_WRITE DIV 1000000, COUNT_READ_ONLY, SUM_TIMER_READ_ONLY DIV 1000000
+	FROM performance_schema.events_transactions_summary_by_account_by_event_name
+	WHERE EVENT_NAME = 'transaction' AND USER IS NOT NULL AND HOST IS NOT NULL`
+
+	transactionConsumerQuery = `SELECT ENABLED FROM performance_schema.setup_consumers
+	WHERE NAME = 'events_transactions_history_long'`
+
+	transactionHistoryQuery = `SELECT THREAD_ID, EVENT_ID, STATE
+	FROM performance_schema.events_transactions_history_long
+	WHERE STATE IN ('COMMITTED', 'ROLLED BACK')`
+)
+
+// transactionEnds counts committed and rolled back transactions seen in
+// transaction history across collections.
+type transactionEnds struct {
+	mutex sync.Mutex
+	// the latest event id seen for each thread
+	last       map[int64]int64
+	committed  int64
+	rolledBack int64
+	// number of all transactions at the last update
+	total   int64
+	started bool
+}
+
+// update counts transactions of history rows which weren't seen before and
+// returns total numbers of committed and rolled back transactions. Events of
+// each thread have increasing ids, so rows with id not greater than the
+// latest one seen for their thread are skipped.
+// count is current number of all transactions. History holds limited number
+// of transactions, when it grew by more than number of new rows, some rows
+// were evicted and new rows are only a sample: increase of count is split
+// between counters in proportion of the sample. ok is false when there is no
+// sample to split by, uncounted transactions are split by the next sample.
+func (te *transactionEnds) update(count int64, rows []transactionEnd) (committed, rolledBack int64, ok bool) {
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+
+	var newCommitted, newRolledBack int64
+
+	last := map[int64]int64{}
+	for _, row := range rows {
+		if row.eventID > te.last[row.threadID] {
+			if row.state == "COMMITTED" {
+				newCommitted++
+			} else {
+				newRolledBack++
+			}
+		}
+		if row.eventID > last[row.threadID] {
+			last[row.threadID] = row.eventID
+		}
+	}
+	// threads missing from history won't report events seen before
+	te.last = last
+
+	sample := newCommitted + newRolledBack
+	delta := count - te.total
+
+	switch {
+	case !te.started || delta < 0:
+		// first update or statistics were reset, counters start from
+		// transactions present in history
+		te.committed += newCommitted
+		te.rolledBack += newRolledBack
+	case sample >= delta:
+		te.committed += newCommitted
+		te.rolledBack += newRolledBack
+	case sample > 0:
+		split := delta * newCommitted / sample
+		te.committed += split
+		te.rolledBack += delta - split
+	default:
+		return 0, 0, false
+	}
+	te.total = count
+	te.started = true
+
+	return te.committed, te.rolledBack, true
+}
+
+// transactionEnd is row of transaction history.
+type transactionEnd struct {
+	threadID int64
+	eventID  int64
+	state    string
+}
+
+// GetTransactions queries database for number and latency of transactions,
+// read-only and read-write ones are reported separately. Numbers of committed
+// and rolled back transactions are counted from transaction history, they are
+// null when consumer events_transactions_history_long is disabled.
+// Transactions of each account are reported when Options.TransactionAccounts
+// is set.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetTransactions() (Stats, error) {
+	rows, err := mysql.transactions.Query()
+	if err != nil {
+		return nil, fmt.Errorf("transactions request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+	var count interface{}
+
+	for rows.Next() {
+		var values [7]interface{}
+
+		err = rows.Scan(&values[0], &values[1], &values[2], &values[3], &values[4], &values[5], &values[6])
+		if err != nil {
+			return nil, fmt.Errorf("transactions request failed: %v", err)
+		}
+
+		count = values[0]
+		addTransactions(stats, "transactions/", values)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("transactions request failed: %v", err)
+	}
+
+	stats["transactions/committed"] = Stat{Type: Counter, IsNull: true}
+	stats["transactions/rolled_back"] = Stat{Type: Counter, IsNull: true}
+
+	enabled, err := mysql.transactionHistoryEnabled()
+	if err != nil {
+		return nil, fmt.Errorf("transactions request failed: %v", err)
+	}
+
+	if enabled && count != nil {
+		ends, err := mysql.transactionHistoryEnds()
+		if err != nil {
+			return nil, fmt.Errorf("transactions request failed: %v", err)
+		}
+		if committed, rolledBack, ok := mysql.transactionEnds.update(toInt(count), ends); ok {
+			stats["transactions/committed"] = counter(committed)
+			stats["transactions/rolled_back"] = counter(rolledBack)
+		}
+	}
+
+	if mysql.accountTransactions == nil {
+		return stats, nil
+	}
+
+	rows, err = mysql.accountTransactions.Query()
+	if err != nil {
+		return nil, fmt.Errorf("transactions request failed: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user, host string
+		var values [7]interface{}
+
+		err = rows.Scan(&user, &host, &values[0], &values[1], &values[2], &values[3], &values[4], &values[5], &values[6])
+		if err != nil {
+			return nil, fmt.Errorf("transactions request failed: %v", err)
+		}
+
+		addTransactions(stats, accountPrefix(user, host)+"/transactions/", values)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("transactions request failed: %v", err)
+	}
+
+	return stats, nil
+}
+
+// addTransactions adds transaction stats selected by transaction queries
+// (count, total and average latency, then count and latency of read-write
+// and read-only transactions) to stats under given prefix.
+func addTransactions(stats Stats, prefix string, values [7]interface{}) {
+	stats[prefix+"count"] = counter(values[0])
+	stats[prefix+"latency"] = counter(values[1])
+	stats[prefix+"avg_latency"] = gauge(values[2])
+	stats[prefix+"read_write/count"] = counter(values[3])
+	stats[prefix+"read_write/latency"] = counter(values[4])
+	stats[prefix+"read_only/count"] = counter(values[5])
+	stats[prefix+"read_only/latency"] = counter(values[6])
+}
+
+// transactionHistoryEnabled checks if consumer of transaction history is
+// enabled.
+func (mysql *MySQLStats) transactionHistoryEnabled() (bool, error) {
+	rows, err := mysql.transactionConsumer.Query()
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	enabled := false
+	for rows.Next() {
+		var value string
+		if err = rows.Scan(&value); err != nil {
+			return false, err
+		}
+		enabled = value == "YES"
+	}
+
+	return enabled, rows.Err()
+}
+
+// transactionHistoryEnds returns ends of transactions present in transaction
+// history.
+func (mysql *MySQLStats) transactionHistoryEnds() ([]transactionEnd, error) {
+	rows, err := mysql.transactionHistory.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []transactionEnd{}
+	for rows.Next() {
+		var row transactionEnd
+		if err = rows.Scan(&row.threadID, &row.eventID, &row.state); err != nil {
+			return nil, err
+		}
+		res = append(res, row)
+	}
+
+	return res, rows.Err()
+}
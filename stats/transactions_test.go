@@ -0,0 +1,178 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetTransactions(t *testing.T) {
+	Convey("GetTransactions", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		columns := []string{"COUNT_STAR", "SUM_TIMER_WAIT", "AVG_TIMER_WAIT",
+			"COUNT_READ_WRITE", "SUM_TIMER_READ_WRITE", "COUNT_READ_ONLY", "SUM_TIMER_READ_ONLY"}
+		historyColumns := []string{"THREAD_ID", "EVENT_ID", "STATE"}
+
+		globalStmt := mock.ExpectPrepare("events_transactions_summary_global_by_event_name")
+		consumerStmt := mock.ExpectPrepare("setup_consumers")
+		historyStmt := mock.ExpectPrepare("events_transactions_history_long")
+		accountStmt := mock.ExpectPrepare("events_transactions_summary_by_account_by_event_name")
+
+		// expect returns given number of transactions and history rows on the
+		// next call, history is queried only when its consumer is enabled
+		expect := func(count int, consumer string, history *sqlmock.Rows) {
+			globalStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(count, 90000, 60, 1000, 80000, 500, 10000))
+			consumerStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"ENABLED"}).AddRow(consumer))
+			if consumer == "YES" {
+				historyStmt.ExpectQuery().WillReturnRows(history)
+			}
+		}
+
+		expect(1500, "YES", sqlmock.NewRows(historyColumns).
+			AddRow(30, 10, "COMMITTED").
+			AddRow(30, 14, "ROLLED BACK").
+			AddRow(31, 7, "COMMITTED"))
+		accountStmt.ExpectQuery().WillReturnRows(sqlmock.NewRows(append([]string{"USER", "HOST"}, columns...)).
+			AddRow("app", "10.0.0.5", 1200, 84000, 70, 900, 78000, 300, 6000))
+
+		sut := &MySQLStats{
+			db:                  db,
+			transactions:        prepareOptional(db, transactionsQuery),
+			transactionConsumer: prepareOptional(db, transactionConsumerQuery),
+			transactionHistory:  prepareOptional(db, transactionHistoryQuery),
+			accountTransactions: prepareOptional(db, accountTransactionsQuery),
+		}
+
+		dut, err := sut.GetTransactions()
+
+		// collects again without accounts
+		next := func() Stats {
+			sut.accountTransactions = nil
+			res, err := sut.GetTransactions()
+			So(err, ShouldBeNil)
+			return res
+		}
+
+		Convey("reports read-write and read-only transactions", func() {
+
+			So(err, ShouldBeNil)
+			So(dut["transactions/count"].Value, ShouldEqual, 1500)
+			So(dut["transactions/count"].Type, ShouldEqual, Counter)
+			So(dut["transactions/latency"].Value, ShouldEqual, 90000)
+			So(dut["transactions/avg_latency"].Value, ShouldEqual, 60)
+			So(dut["transactions/avg_latency"].Type, ShouldEqual, Gauge)
+			So(dut["transactions/read_write/count"].Value, ShouldEqual, 1000)
+			So(dut["transactions/read_write/latency"].Value, ShouldEqual, 80000)
+			So(dut["transactions/read_only/count"].Value, ShouldEqual, 500)
+			So(dut["transactions/read_only/latency"].Value, ShouldEqual, 10000)
+
+		})
+
+		Convey("reports committed and rolled back transactions", func() {
+
+			So(dut["transactions/committed"].Value, ShouldEqual, 2)
+			So(dut["transactions/committed"].Type, ShouldEqual, Counter)
+			So(dut["transactions/rolled_back"].Value, ShouldEqual, 1)
+
+		})
+
+		Convey("counts only transactions which weren't seen before", func() {
+
+			expect(1502, "YES", sqlmock.NewRows(historyColumns).
+				AddRow(30, 14, "ROLLED BACK").
+				AddRow(30, 20, "COMMITTED").
+				AddRow(32, 3, "ROLLED BACK"))
+
+			dut2 := next()
+
+			So(dut2["transactions/committed"].Value, ShouldEqual, 3)
+			So(dut2["transactions/rolled_back"].Value, ShouldEqual, 2)
+
+		})
+
+		Convey("splits transactions evicted from history by sample of new ones", func() {
+
+			expect(1600, "YES", sqlmock.NewRows(historyColumns).
+				AddRow(30, 20, "COMMITTED").
+				AddRow(30, 21, "COMMITTED").
+				AddRow(32, 3, "COMMITTED").
+				AddRow(33, 5, "ROLLED BACK"))
+
+			dut2 := next()
+
+			So(dut2["transactions/committed"].Value, ShouldEqual, 2+75)
+			So(dut2["transactions/rolled_back"].Value, ShouldEqual, 1+25)
+
+		})
+
+		Convey("reports null when evicted transactions can't be split", func() {
+
+			expect(1550, "YES", sqlmock.NewRows(historyColumns))
+
+			dut2 := next()
+
+			So(dut2["transactions/committed"].IsNull, ShouldBeTrue)
+			So(dut2["transactions/rolled_back"].IsNull, ShouldBeTrue)
+
+			Convey("and splits them by the next sample", func() {
+
+				expect(1600, "YES", sqlmock.NewRows(historyColumns).
+					AddRow(30, 20, "COMMITTED").
+					AddRow(30, 21, "ROLLED BACK"))
+
+				dut3 := next()
+
+				So(dut3["transactions/committed"].Value, ShouldEqual, 2+50)
+				So(dut3["transactions/rolled_back"].Value, ShouldEqual, 1+50)
+
+			})
+
+		})
+
+		Convey("reports null when history consumer is disabled", func() {
+
+			expect(1502, "NO", nil)
+
+			dut2 := next()
+
+			So(dut2["transactions/count"].Value, ShouldEqual, 1502)
+			So(dut2["transactions/committed"].IsNull, ShouldBeTrue)
+			So(dut2["transactions/rolled_back"].IsNull, ShouldBeTrue)
+			So(mock.ExpectationsWereMet(), ShouldBeNil)
+
+		})
+
+		Convey("reports transactions of accounts", func() {
+
+			So(dut["account/[user=app]/[host=10.0.0.5]/transactions/count"].Value, ShouldEqual, 1200)
+			So(dut["account/[user=app]/[host=10.0.0.5]/transactions/read_only/count"].Value, ShouldEqual, 300)
+
+		})
+
+	})
+}
@@ -0,0 +1,96 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"strings"
+)
+
+// waitsQuery selects wait statistics of instruments of reported wait classes,
+// timers are converted from picoseconds to microseconds.
+const waitsQuery = `SELECT EVENT_NAME, COUNT_STAR, SUM_TIMER_WAIT DIV 1000000
+	FROM performance_schema.events_waits_summary_global_by_event_name
+	WHERE EVENT_NAME LIKE 'wait/io/file/%' OR EVENT_NAME LIKE 'wait/synch/mutex/%'
+	OR EVENT_NAME LIKE 'wait/synch/rwlock/%' OR EVENT_NAME LIKE 'wait/lock/table/%'`
+
+// waitClasses lists reported wait classes, idle waits are not reported.
+var waitClasses = []string{"io/file", "synch/mutex", "synch/rwlock", "lock/table"}
+
+// synchClassPrefix is prefix of classes of synchronization instruments,
+// their statistics are reported individually by GetContention.
+const synchClassPrefix = "synch/"
+
+// GetWaits queries database for wait statistics of file I/O, mutex, rw-lock
+// and table lock instruments. Totals of each wait class and statistics of
+// each file I/O and table lock instrument are reported, instrument name (ex.
+// io/file/sql/binlog) is a namespace element.
+// If query succeeded appropriate collection of stats is returned, otherwise
+// error is returned.
+func (mysql *MySQLStats) GetWaits() (Stats, error) {
+	rows, err := mysql.waits.Query()
+	if err != nil {
+		return nil, fmt.Errorf("waits request failed: %v", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{}
+
+	counts := map[string]int64{}
+	latencies := map[string]int64{}
+
+	for rows.Next() {
+		var name string
+		var count, latency interface{}
+
+		err = rows.Scan(&name, &count, &latency)
+		if err != nil {
+			return nil, fmt.Errorf("waits request failed: %v", err)
+		}
+
+		event := strings.TrimPrefix(name, "wait/")
+
+		for _, class := range waitClasses {
+			if strings.HasPrefix(event, class+"/") {
+				counts[class] += toInt(count)
+				latencies[class] += toInt(latency)
+			}
+		}
+
+		if strings.HasPrefix(event, synchClassPrefix) {
+			continue
+		}
+
+		prefix := "waits/event/" + Dynamic("event", event) + "/"
+		stats[prefix+"count"] = counter(count)
+		stats[prefix+"latency"] = counter(latency)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("waits request failed: %v", err)
+	}
+
+	for _, class := range waitClasses {
+		stats["waits/"+class+"/count"] = counter(counts[class])
+		stats["waits/"+class+"/latency"] = counter(latencies[class])
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,75 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetWaits(t *testing.T) {
+	Convey("GetWaits", t, func() {
+
+		db, mock, _ := sqlmock.New()
+
+		mock.ExpectPrepare("events_waits_summary_global_by_event_name").ExpectQuery().WillReturnRows(
+			sqlmock.NewRows([]string{"EVENT_NAME", "COUNT_STAR", "SUM_TIMER_WAIT"}).
+				AddRow("wait/io/file/innodb/innodb_log_file", 100, 5000).
+				AddRow("wait/io/file/sql/binlog", 50, 1000).
+				AddRow("wait/synch/mutex/sql/THD::LOCK_thd_data", 1000, 20).
+				AddRow("wait/synch/rwlock/innodb/dict_operation_lock", 10, 30).
+				AddRow("wait/lock/table/sql/handler", 500, 800))
+
+		sut := &MySQLStats{db: db, waits: prepareOptional(db, waitsQuery)}
+
+		dut, err := sut.GetWaits()
+
+		Convey("reports statistics of each instrument", func() {
+
+			So(err, ShouldBeNil)
+			So(dut["waits/event/[event=io_file_innodb_innodb_log_file]/count"].Value, ShouldEqual, 100)
+			So(dut["waits/event/[event=io_file_innodb_innodb_log_file]/count"].Type, ShouldEqual, Counter)
+			So(dut["waits/event/[event=io_file_innodb_innodb_log_file]/latency"].Value, ShouldEqual, 5000)
+			So(dut["waits/event/[event=lock_table_sql_handler]/latency"].Value, ShouldEqual, 800)
+
+		})
+
+		Convey("leaves synchronization instruments to contention", func() {
+
+			So(dut, ShouldNotContainKey, "waits/event/[event=synch_mutex_sql_THD::LOCK_thd_data]/count")
+
+		})
+
+		Convey("reports totals of each wait class", func() {
+
+			So(dut["waits/io/file/count"].Value, ShouldEqual, 150)
+			So(dut["waits/io/file/latency"].Value, ShouldEqual, 6000)
+			So(dut["waits/synch/mutex/count"].Value, ShouldEqual, 1000)
+			So(dut["waits/synch/rwlock/latency"].Value, ShouldEqual, 30)
+			So(dut["waits/lock/table/count"].Value, ShouldEqual, 500)
+
+		})
+
+	})
+}